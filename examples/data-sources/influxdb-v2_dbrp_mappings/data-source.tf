@@ -0,0 +1,8 @@
+data "influxdb-v2_dbrp_mappings" "telegraf" {
+  org_id   = "example_org_id"
+  database = "telegraf"
+}
+
+output "influxdb-v2_telegraf_retention_policies" {
+  value = data.influxdb-v2_dbrp_mappings.telegraf.mappings[*].retention_policy
+}
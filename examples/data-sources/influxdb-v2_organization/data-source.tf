@@ -4,4 +4,8 @@ data "influxdb-v2_organization" "organization" {
 
 output "influxdb-v2_organization_id" {
   value = data.influxdb-v2_organization.organization.id
+}
+
+output "influxdb-v2_organization_owners" {
+  value = data.influxdb-v2_organization.organization.owners[*].name
 }
\ No newline at end of file
@@ -0,0 +1,22 @@
+data "influxdb-v2_permissions" "telegraf" {
+  org_id        = "example_org_id"
+  read_buckets  = ["example_bucket_id"]
+  write_buckets = ["example_bucket_id"]
+}
+
+resource "influxdb-v2_authorization" "telegraf" {
+  org_id      = "example_org_id"
+  description = "Telegraf token"
+
+  dynamic "permissions" {
+    for_each = data.influxdb-v2_permissions.telegraf.permissions
+    content {
+      action = permissions.value.action
+      resource {
+        id     = permissions.value.id
+        org_id = permissions.value.org_id
+        type   = permissions.value.type
+      }
+    }
+  }
+}
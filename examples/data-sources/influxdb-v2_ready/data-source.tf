@@ -1,5 +1,11 @@
-data "influxdb-v2_ready" "test" {}
+data "influxdb-v2_ready" "test" {
+  wait_timeout = "2m"
+}
 
 output "influxdb-v2_ready" {
   value = data.influxdb-v2_ready.test.output["url"]
-}
\ No newline at end of file
+}
+
+output "influxdb-v2_version" {
+  value = data.influxdb-v2_ready.test.version
+}
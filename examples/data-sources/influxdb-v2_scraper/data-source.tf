@@ -0,0 +1,7 @@
+data "influxdb-v2_scraper" "scraper" {
+  name = "node_exporter"
+}
+
+output "influxdb-v2_scraper" {
+  value = data.influxdb-v2_scraper.scraper
+}
@@ -0,0 +1,7 @@
+data "influxdb-v2_scrapers" "scrapers" {
+  org_id = "example_org_id"
+}
+
+output "influxdb-v2_scraper_urls" {
+  value = data.influxdb-v2_scrapers.scrapers.scrapers[*].url
+}
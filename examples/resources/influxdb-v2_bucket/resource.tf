@@ -9,4 +9,20 @@ resource "influxdb-v2_bucket" "example_bucket" {
   retention_rules {
     every_seconds = 0
   }
+}
+
+resource "influxdb-v2_bucket" "migrated_bucket" {
+  name                = "migrated_bucket_name"
+  org_id              = local.org_id
+  rp                  = "autogen"
+  deletion_protection = false
+  on_replace          = "copy"
+  retention_rules {
+    every = "30d"
+  }
+  dbrp {
+    database         = "migrated"
+    retention_policy = "autogen"
+    default          = true
+  }
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+locals {
+  org_id    = "example_org_id"
+  bucket_id = "example_bucket_id"
+}
+
+resource "influxdb-v2_delete_data" "gdpr_request_1234" {
+  org_id    = local.org_id
+  bucket_id = local.bucket_id
+  start     = "1970-01-01T00:00:00Z"
+  stop      = "2024-06-01T00:00:00Z"
+  predicate = "_measurement=\"sessions\" AND user=\"1234\""
+  triggers = {
+    ticket = "GDPR-1234"
+  }
+}
@@ -1,4 +1,5 @@
 resource "influxdb-v2_organization" "example_organization" {
-  name        = "Example organization name"
-  description = "Example organization description"
+  name                        = "Example organization name"
+  description                 = "Example organization description"
+  prevent_destroy_if_nonempty = true
 }
\ No newline at end of file
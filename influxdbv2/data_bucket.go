@@ -70,14 +70,16 @@ func dataSourceBucket() *schema.Resource {
 
 func dataSourceBucketRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	// use the meta value to retrieve your client from the provider configure method
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	bucketAPI := influx.BucketsAPI()
 
 	// Warning or errors can be collected in a slice type
 	var (
 		diags  diag.Diagnostics
 		bucket *domain.Bucket
-		err    error
 	)
 
 	if v, ok := d.GetOk("name"); ok {
@@ -9,7 +9,7 @@ import (
 
 // TestAccReadBucket tests the read bucket data source
 func TestAccReadBucket(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:  func() { testAccPreCheck(t) },
 		Providers: testAccProviders,
 		Steps: []resource.TestStep{
@@ -29,6 +29,7 @@ func TestAccReadBucket(t *testing.T) {
 func testDataSourceBucketConfig() string {
 	return `resource "influxdb-v2_bucket" "bucket" {
 			name = "AcctestBucket"
+			deletion_protection = false
 			description = "Desc Acctest"
 			org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
     		retention_rules {
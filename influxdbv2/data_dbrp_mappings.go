@@ -0,0 +1,157 @@
+package influxdbv2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+func dataSourceDBRPMappings() *schema.Resource {
+	return &schema.Resource{
+		Description: "List the DBRP mappings of an organization in InfluxDB2.",
+		ReadContext: dataSourceDBRPMappingsRead,
+
+		Schema: map[string]*schema.Schema{
+			"org_id": {
+				Description: "ID of the organization of the mappings. Defaults to the organization of the provider.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"bucket_id": {
+				Description: "Only list the mappings to this bucket.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"database": {
+				Description: "Only list the mappings of this database.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"retention_policy": {
+				Description: "Only list the mappings of this retention policy.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"default_policy": {
+				Description: "Only list the default mappings when true, or the others when false.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
+			"mappings": {
+				Description: "Mappings found.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Description: "Mapping id.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"org_id": {
+							Description: "ID of the organization of the mapping.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"bucket_id": {
+							Description: "ID of the bucket that queries of the database and retention policy read.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"database": {
+							Description: "InfluxDB v1 database.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"retention_policy": {
+							Description: "InfluxDB v1 retention policy.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"default_policy": {
+							Description: "Whether this is the default retention policy of the database.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+						"virtual": {
+							Description: "Whether the server made the mapping from the name of the bucket.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceDBRPMappingsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	influx, err := m.(*meta).client(nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	orgID := d.Get("org_id").(string)
+	if orgID == "" {
+		orgID, err = m.(*meta).getDefaultOrgID(nil)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if orgID == "" {
+			return diag.Errorf("org_id must be set on the data source or an organization on the provider")
+		}
+	}
+	bucketID := d.Get("bucket_id").(string)
+	database := d.Get("database").(string)
+	rp := d.Get("retention_policy").(string)
+	params := &domain.GetDBRPsParams{OrgID: &orgID}
+	if bucketID != "" {
+		params.BucketID = &bucketID
+	}
+	if database != "" {
+		params.Db = &database
+	}
+	if rp != "" {
+		params.Rp = &rp
+	}
+	isDefault := ""
+	if v := d.GetRawConfig().GetAttr("default_policy"); v.IsKnown() && !v.IsNull() {
+		value := v.True()
+		params.Default = &value
+		isDefault = fmt.Sprint(value)
+	}
+
+	result, err := influx.APIClient().GetDBRPs(ctx, params)
+	if err != nil {
+		return diag.Errorf("error getting dbrp mappings: %v", err)
+	}
+	mappings := []map[string]interface{}{}
+	if result.Content != nil {
+		for _, dbrp := range *result.Content {
+			mappings = append(mappings, map[string]interface{}{
+				"id":               dbrp.Id,
+				"org_id":           dbrp.OrgID,
+				"bucket_id":        dbrp.BucketID,
+				"database":         dbrp.Database,
+				"retention_policy": dbrp.RetentionPolicy,
+				"default_policy":   dbrp.Default,
+				"virtual":          dbrp.Virtual != nil && *dbrp.Virtual,
+			})
+		}
+	}
+	err = d.Set("mappings", mappings)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("org_id", orgID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strings.Join([]string{orgID, bucketID, database, rp, isDefault}, "/"))
+	return nil
+}
@@ -0,0 +1,47 @@
+package influxdbv2
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// TestAccReadDBRPMappings tests the dbrp mappings data source
+func TestAccReadDBRPMappings(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testDataSourceDBRPMappingsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.influxdb-v2_dbrp_mappings.by_database", "mappings.#", "1"),
+					resource.TestCheckResourceAttrPair("data.influxdb-v2_dbrp_mappings.by_database", "mappings.0.id", "influxdb-v2_dbrp_mapping.mapping", "id"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_dbrp_mappings.by_database", "mappings.0.retention_policy", "lookup_rp"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_dbrp_mappings.by_database", "mappings.0.default_policy", "true"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_dbrp_mappings.not_default", "mappings.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testDataSourceDBRPMappingsConfig() string {
+	return `resource "influxdb-v2_dbrp_mapping" "mapping" {
+			org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+			bucket_id = "` + os.Getenv("INFLUXDB_V2_BUCKET_ID") + `"
+			database = "lookup_database"
+			retention_policy = "lookup_rp"
+			}
+			data "influxdb-v2_dbrp_mappings" "by_database" {
+				org_id = influxdb-v2_dbrp_mapping.mapping.org_id
+				database = influxdb-v2_dbrp_mapping.mapping.database
+			}
+			data "influxdb-v2_dbrp_mappings" "not_default" {
+				org_id = influxdb-v2_dbrp_mapping.mapping.org_id
+				database = influxdb-v2_dbrp_mapping.mapping.database
+				default_policy = false
+			}
+`
+}
@@ -9,7 +9,7 @@ import (
 // TestAccReadOrganization tests the read organization data source
 func TestAccReadOrganization(t *testing.T) {
 
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:  func() { testAccPreCheck(t) },
 		Providers: testAccProviders,
 		Steps: []resource.TestStep{
@@ -18,6 +18,13 @@ func TestAccReadOrganization(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr("data.influxdb-v2_organization.by_name", "name", "AcctestName"),
 					resource.TestCheckResourceAttr("data.influxdb-v2_organization.by_name", "description", "Desc Acctest"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_organization.by_id", "name", "AcctestName"),
+					// The organization is owned by the user who onboarded the instance in scripts/setup_influxdb.sh
+					resource.TestCheckResourceAttr("data.influxdb-v2_organization.by_id", "owners.#", "1"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_organization.by_id", "owners.0.name", "admin"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_organization.by_id", "owners.0.role", "owner"),
+					resource.TestCheckResourceAttrSet("data.influxdb-v2_organization.by_id", "owners.0.id"),
+					resource.TestCheckResourceAttrSet("data.influxdb-v2_organization.by_id", "members.#"),
 				),
 			},
 		},
@@ -34,5 +41,8 @@ func testDataSourceOrganizationConfig() string {
 			//requirement of terraform v0.13
 			depends_on = [influxdb-v2_organization.org]
 		}
+		data "influxdb-v2_organization" "by_id" {
+			id = influxdb-v2_organization.org.id
+		}
 `
 }
@@ -20,35 +20,83 @@ func dataSourceOrganization() *schema.Resource {
 				Computed:    true,
 				Description: "The description of the Organization.",
 			},
+			"id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "name"},
+				Description:  "ID of the Organization.",
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "name"},
+				Description:  "Name of the Organization.",
+			},
+			"members": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Members of the Organization.",
+				Elem:        organizationUserSchema(),
+			},
+			"owners": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Owners of the Organization.",
+				Elem:        organizationUserSchema(),
+			},
+		}, createUpdatedSchema("Organization")),
+	}
+}
+
+func organizationUserSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:        schema.TypeString,
-				Optional:    true,
 				Computed:    true,
-				Description: "ID of the Organization.",
+				Description: "ID of the user.",
 			},
 			"name": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Computed:    false,
-				Description: "Name of the Organization.",
+				Computed:    true,
+				Description: "Name of the user.",
 			},
-		}, createUpdatedSchema("Organization")),
+			"role": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Role of the user in the Organization.",
+			},
+		},
 	}
 }
 
 func dataSourceOrganizationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	// use the meta value to retrieve your client from the provider configure method
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	orgAPI := influx.OrganizationsAPI()
 
 	// Warning or errors can be collected in a slice type
 	var (
 		diags diag.Diagnostics
 		org   *domain.Organization
-		err   error
 	)
 
-	if v, ok := d.GetOk("name"); ok {
+	if v, ok := d.GetOk("id"); ok {
+		orgID := v.(string)
+		if org, err = orgAPI.FindOrganizationByID(ctx, orgID); err != nil {
+			diags = append(diags, diag.FromErr(err)...)
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Can't find Organization with id: %s", orgID),
+			})
+			return diags
+		}
+	} else if v, ok := d.GetOk("name"); ok {
 		orgName := v.(string)
 		if org, err = orgAPI.FindOrganizationByName(ctx, orgName); err != nil {
 			diags = append(diags, diag.FromErr(err)...)
@@ -100,5 +148,51 @@ func dataSourceOrganizationRead(ctx context.Context, d *schema.ResourceData, m i
 	if err != nil {
 		return nil
 	}
+
+	members, err := orgAPI.GetMembers(ctx, org)
+	if err != nil {
+		return diag.Errorf("error getting members of organization: %v", err)
+	}
+	var flatMembers []map[string]interface{}
+	for _, member := range *members {
+		role := ""
+		if member.Role != nil {
+			role = string(*member.Role)
+		}
+		flatMembers = append(flatMembers, flattenOrganizationUser(member.UserResponse, role))
+	}
+	err = d.Set("members", flatMembers)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	owners, err := orgAPI.GetOwners(ctx, org)
+	if err != nil {
+		return diag.Errorf("error getting owners of organization: %v", err)
+	}
+	var flatOwners []map[string]interface{}
+	for _, owner := range *owners {
+		role := ""
+		if owner.Role != nil {
+			role = string(*owner.Role)
+		}
+		flatOwners = append(flatOwners, flattenOrganizationUser(owner.UserResponse, role))
+	}
+	err = d.Set("owners", flatOwners)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	return diags
 }
+
+func flattenOrganizationUser(user domain.UserResponse, role string) map[string]interface{} {
+	id := ""
+	if user.Id != nil {
+		id = *user.Id
+	}
+	return map[string]interface{}{
+		"id":   id,
+		"name": user.Name,
+		"role": role,
+	}
+}
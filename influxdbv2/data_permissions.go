@@ -0,0 +1,204 @@
+package influxdbv2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+func dataSourcePermissions() *schema.Resource {
+	return &schema.Resource{
+		Description: "Generate the permissions of an Authorization from what it is for, as the InfluxDB2 UI does.",
+		ReadContext: dataSourcePermissionsRead,
+
+		Schema: map[string]*schema.Schema{
+			"org_id": {
+				Description: "ID of the organization of the permissions. Defaults to the organization of the provider.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"user_id": {
+				Description:   "ID of the user of the token, given read and write on itself by all_access.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"org_admin"},
+			},
+			"read_buckets": {
+				Description: "IDs of the buckets to read.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"write_buckets": {
+				Description: "IDs of the buckets to write.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"all_access": {
+				Description: "Read and write everything in the organization, like an All Access API Token of the UI. Unlike org_admin, also reads and writes the user of user_id, without which both are the same.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
+			"org_admin": {
+				Description: "Read and write everything in the organization, like its owners, but no user.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
+			"permissions": {
+				Description: "Permissions to give to an Authorization.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"action": {
+							Description: "read or write.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"type": {
+							Description: "Type of the resource.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"id": {
+							Description: "ID of the resource, empty for every resource of the type.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"org_id": {
+							Description: "ID of the organization of the resource.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePermissionsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	orgID := d.Get("org_id").(string)
+	if orgID == "" {
+		var err error
+		orgID, err = m.(*meta).getDefaultOrgID(nil)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if orgID == "" {
+			return diag.Errorf("org_id must be set on the data source or an organization on the provider")
+		}
+	}
+
+	var permissions []domain.Permission
+	for _, id := range d.Get("read_buckets").(*schema.Set).List() {
+		permissions = append(permissions, newPermission(domain.PermissionActionRead, domain.ResourceTypeBuckets, id.(string), orgID))
+	}
+	for _, id := range d.Get("write_buckets").(*schema.Set).List() {
+		permissions = append(permissions, newPermission(domain.PermissionActionWrite, domain.ResourceTypeBuckets, id.(string), orgID))
+	}
+
+	allAccess := d.Get("all_access").(bool)
+	if allAccess || d.Get("org_admin").(bool) {
+		influx, err := m.(*meta).client(nil)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		// The types of resources depend on the version of the server
+		types, err := influx.APIClient().GetResources(ctx, &domain.GetResourcesParams{})
+		if err != nil {
+			return diag.Errorf("error getting resource types: %v", err)
+		}
+		userID := ""
+		if allAccess {
+			userID = d.Get("user_id").(string)
+		}
+		permissions = append(permissions, ownerPermissions(*types, orgID, userID)...)
+	}
+
+	flat := flattenPermissions(permissions)
+	err := d.Set("permissions", flat)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("org_id", orgID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	var keys []string
+	for _, permission := range flat {
+		keys = append(keys, fmt.Sprint(permission["type"], "/", permission["id"], "/", permission["org_id"], "/", permission["action"]))
+	}
+	d.SetId(fmt.Sprint(schema.HashString(strings.Join(keys, ","))))
+	return nil
+}
+
+// ownerPermissions gives read and write on every type of resource of an
+// organization but the instance, which is the same as its owners have. The
+// organization itself is given by its ID. Users are only given when userID is
+// set, on that user alone, as the UI does for its All Access API Tokens.
+func ownerPermissions(types []string, orgID, userID string) []domain.Permission {
+	var permissions []domain.Permission
+	for _, t := range types {
+		resourceType := domain.ResourceType(t)
+		for _, action := range []domain.PermissionAction{domain.PermissionActionRead, domain.PermissionActionWrite} {
+			switch resourceType {
+			case domain.ResourceTypeInstance:
+			case domain.ResourceTypeOrgs:
+				permissions = append(permissions, newPermission(action, resourceType, orgID, ""))
+			case domain.ResourceTypeUsers:
+				if userID != "" {
+					permissions = append(permissions, newPermission(action, resourceType, userID, ""))
+				}
+			default:
+				permissions = append(permissions, newPermission(action, resourceType, "", orgID))
+			}
+		}
+	}
+	return permissions
+}
+
+func newPermission(action domain.PermissionAction, resourceType domain.ResourceType, id, orgID string) domain.Permission {
+	return domain.Permission{
+		Action:   action,
+		Resource: domain.Resource{Type: resourceType, Id: optionalString(id), OrgID: optionalString(orgID)},
+	}
+}
+
+// flattenPermissions removes duplicated permissions and sorts them by type,
+// ID and action, so that the output only changes with the permissions.
+func flattenPermissions(permissions []domain.Permission) []map[string]interface{} {
+	seen := map[string]bool{}
+	flat := []map[string]interface{}{}
+	for _, permission := range permissions {
+		p := map[string]interface{}{
+			"action": string(permission.Action),
+			"type":   string(permission.Resource.Type),
+			"id":     stringValue(permission.Resource.Id),
+			"org_id": stringValue(permission.Resource.OrgID),
+		}
+		key := fmt.Sprint(p["type"], "/", p["id"], "/", p["org_id"], "/", p["action"])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		flat = append(flat, p)
+	}
+	sort.SliceStable(flat, func(i, j int) bool {
+		a, b := flat[i], flat[j]
+		for _, k := range []string{"type", "id", "org_id", "action"} {
+			if a[k] != b[k] {
+				return a[k].(string) < b[k].(string)
+			}
+		}
+		return false
+	})
+	return flat
+}
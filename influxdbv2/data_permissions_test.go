@@ -0,0 +1,156 @@
+package influxdbv2
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+// TestAccReadPermissions tests the permissions data source and gives its
+// permissions to an authorization
+func TestAccReadPermissions(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccAuthorizationDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: testDataSourcePermissionsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.buckets", "permissions.#", "2"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.buckets", "permissions.0.action", "read"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.buckets", "permissions.0.type", "buckets"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.buckets", "permissions.0.id", os.Getenv("INFLUXDB_V2_BUCKET_ID")),
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.buckets", "permissions.0.org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.buckets", "permissions.1.action", "write"),
+					resource.TestCheckResourceAttr("influxdb-v2_authorization.acctest", "permissions.#", "2"),
+					resource.TestCheckResourceAttrSet("data.influxdb-v2_permissions.admin", "permissions.#"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_permissions.admin", "org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+				),
+			},
+		},
+	})
+}
+
+func testDataSourcePermissionsConfig() string {
+	return `data "influxdb-v2_permissions" "buckets" {
+			org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+			read_buckets = ["` + os.Getenv("INFLUXDB_V2_BUCKET_ID") + `"]
+			write_buckets = ["` + os.Getenv("INFLUXDB_V2_BUCKET_ID") + `"]
+			}
+			data "influxdb-v2_permissions" "admin" {
+				org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+				org_admin = true
+			}
+			resource "influxdb-v2_authorization" "acctest" {
+				org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+				description = "Acceptance test permissions token"
+				dynamic "permissions" {
+					for_each = data.influxdb-v2_permissions.buckets.permissions
+					content {
+						action = permissions.value.action
+						resource {
+							id = permissions.value.id
+							org_id = permissions.value.org_id
+							type = permissions.value.type
+						}
+					}
+				}
+			}
+`
+}
+
+// TestAccPermissionsPresets gives the permissions of the org_admin and
+// all_access presets to authorizations, which the server must accept
+func TestAccPermissionsPresets(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccAuthorizationDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+data "influxdb-v2_permissions" "admin" {
+	org_admin = true
+	user_id = "0000000000000001"
+}
+`,
+				ExpectError: regexp.MustCompile(`"user_id": conflicts with org_admin`),
+			},
+			{
+				Config: testAccPermissionsPresetsConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("influxdb-v2_authorization.admin", "permissions.#", "data.influxdb-v2_permissions.admin", "permissions.#"),
+					resource.TestCheckResourceAttrPair("influxdb-v2_authorization.all_access", "permissions.#", "data.influxdb-v2_permissions.all_access", "permissions.#"),
+					resource.TestCheckResourceAttrSet("influxdb-v2_authorization.admin", "token"),
+					resource.TestCheckResourceAttrSet("influxdb-v2_authorization.all_access", "token"),
+				),
+			},
+		},
+	})
+}
+
+func testAccPermissionsPresetsConfig() string {
+	authorization := func(name string) string {
+		return `
+resource "influxdb-v2_authorization" "` + name + `" {
+	org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+	description = "Acceptance test ` + name + ` token"
+	dynamic "permissions" {
+		for_each = data.influxdb-v2_permissions.` + name + `.permissions
+		content {
+			action = permissions.value.action
+			resource {
+				id = permissions.value.id
+				org_id = permissions.value.org_id
+				type = permissions.value.type
+			}
+		}
+	}
+}
+`
+	}
+	return `
+data "influxdb-v2_organization" "testorg" {
+	id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+}
+data "influxdb-v2_permissions" "admin" {
+	org_admin = true
+}
+data "influxdb-v2_permissions" "all_access" {
+	all_access = true
+	user_id = data.influxdb-v2_organization.testorg.owners[0].id
+}
+` + authorization("admin") + authorization("all_access")
+}
+
+func TestOwnerPermissions(t *testing.T) {
+	types := []string{"buckets", "instance", "orgs", "users"}
+
+	flat := flattenPermissions(ownerPermissions(types, "org", ""))
+	expected := []map[string]interface{}{
+		{"action": "read", "type": "buckets", "id": "", "org_id": "org"},
+		{"action": "write", "type": "buckets", "id": "", "org_id": "org"},
+		{"action": "read", "type": "orgs", "id": "org", "org_id": ""},
+		{"action": "write", "type": "orgs", "id": "org", "org_id": ""},
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected org admin permissions %v but got %v", expected, flat)
+	}
+
+	// All access adds the user, and buckets given on their own are merged
+	permissions := []domain.Permission{newPermission(domain.PermissionActionWrite, domain.ResourceTypeBuckets, "", "org")}
+	permissions = append(permissions, ownerPermissions(types, "org", "user")...)
+	flat = flattenPermissions(permissions)
+	expected = append(expected,
+		map[string]interface{}{"action": "read", "type": "users", "id": "user", "org_id": ""},
+		map[string]interface{}{"action": "write", "type": "users", "id": "user", "org_id": ""},
+	)
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("expected all access permissions %v but got %v", expected, flat)
+	}
+}
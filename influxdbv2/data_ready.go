@@ -4,43 +4,192 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
 func DataReady() *schema.Resource {
 	return &schema.Resource{
 		Read: DataGetReady,
 		Schema: map[string]*schema.Schema{
+			"wait_timeout": {
+				Type:         schema.TypeString,
+				Description:  "How long to wait for the server to be ready and healthy, such as 30s or 5m.",
+				Optional:     true,
+				Default:      "0s",
+				ValidateFunc: validateDuration,
+			},
+			"poll_interval": {
+				Type:         schema.TypeString,
+				Description:  "How long to wait between two checks of the server.",
+				Optional:     true,
+				Default:      "1s",
+				ValidateFunc: validateDuration,
+			},
 			"output": {
 				Type:     schema.TypeMap,
 				Computed: true,
 			},
+			"status": {
+				Type:        schema.TypeString,
+				Description: "Status reported by /ready.",
+				Computed:    true,
+			},
+			"started": {
+				Type:        schema.TypeString,
+				Description: "When the server started, as an RFC3339 time.",
+				Computed:    true,
+			},
+			"uptime": {
+				Type:        schema.TypeString,
+				Description: "How long the server has been up.",
+				Computed:    true,
+			},
+			"health": {
+				Type:        schema.TypeString,
+				Description: "Status reported by /health.",
+				Computed:    true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"commit": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"checks": {
+				Type:        schema.TypeList,
+				Description: "Health of the dependencies of the server.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
 func DataGetReady(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
-	ready, err := influx.Ready(context.Background())
+	// The connection is used as is, as waiting for the server is what this
+	// data source does
+	c, err := m.(*meta).connection(nil)
 	if err != nil {
-		return fmt.Errorf("server is not ready: %v", err)
+		return err
 	}
-	if *ready.Status != "ready" {
-		log.Printf("Server is ready !")
+	influx := c.influxsdk
+	timeout, _ := time.ParseDuration(d.Get("wait_timeout").(string))
+	interval, _ := time.ParseDuration(d.Get("poll_interval").(string))
+
+	ready, health, err := waitForReady(context.Background(), influx, timeout, interval)
+	if err != nil {
+		return fmt.Errorf("server is not ready: %v", err)
 	}
+	log.Printf("[INFO] server is ready")
 
 	output := map[string]string{
 		"url": influx.ServerURL(),
 	}
 
-	id := ""
-	id = influx.ServerURL()
-	d.SetId(id)
+	d.SetId(influx.ServerURL())
 	err = d.Set("output", output)
 	if err != nil {
 		return err
 	}
+	err = d.Set("status", string(*ready.Status))
+	if err != nil {
+		return err
+	}
+	started := ""
+	if ready.Started != nil {
+		started = ready.Started.Format(time.RFC3339)
+	}
+	err = d.Set("started", started)
+	if err != nil {
+		return err
+	}
+	err = d.Set("uptime", stringValue(ready.Up))
+	if err != nil {
+		return err
+	}
+	err = d.Set("health", string(health.Status))
+	if err != nil {
+		return err
+	}
+	err = d.Set("version", stringValue(health.Version))
+	if err != nil {
+		return err
+	}
+	err = d.Set("commit", stringValue(health.Commit))
+	if err != nil {
+		return err
+	}
+	var checks []map[string]interface{}
+	if health.Checks != nil {
+		for _, check := range *health.Checks {
+			checks = append(checks, map[string]interface{}{
+				"name":    check.Name,
+				"status":  string(check.Status),
+				"message": stringValue(check.Message),
+			})
+		}
+	}
+	err = d.Set("checks", checks)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// waitForReady checks /ready and /health until the server is ready and
+// healthy or the timeout has elapsed.
+func waitForReady(ctx context.Context, influx influxdb2.Client, timeout, interval time.Duration) (*domain.Ready, *domain.HealthCheck, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		ready, health, err := checkReady(ctx, influx)
+		if err == nil || !time.Now().Before(deadline) {
+			return ready, health, err
+		}
+		log.Printf("[DEBUG] waiting for the server to be ready: %s", err)
+		select {
+		case <-ctx.Done():
+			return nil, nil, err
+		case <-time.After(interval):
+		}
+	}
+}
+
+func checkReady(ctx context.Context, influx influxdb2.Client) (*domain.Ready, *domain.HealthCheck, error) {
+	ready, err := influx.Ready(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ready.Status == nil || *ready.Status != domain.ReadyStatusReady {
+		return nil, nil, fmt.Errorf("status is %q", stringValue((*string)(ready.Status)))
+	}
+	health, err := influx.Health(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking health: %v", err)
+	}
+	if health.Status != domain.HealthCheckStatusPass {
+		return nil, nil, fmt.Errorf("health is %q: %s", health.Status, stringValue(health.Message))
+	}
+	return ready, health, nil
+}
@@ -1,10 +1,17 @@
 package influxdbv2
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 )
 
 func TestAccReady(t *testing.T) {
@@ -13,7 +20,7 @@ func TestAccReady(t *testing.T) {
 		currentUrl = "http://localhost:8086"
 	}
 
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		// no need to precheck the token .env var, we don't need it
 		Providers: testAccProviders,
 		Steps: []resource.TestStep{
@@ -21,6 +28,10 @@ func TestAccReady(t *testing.T) {
 				Config: testAccReady(),
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestCheckResourceAttr("data.influxdb-v2_ready.acctest", "output.url", currentUrl),
+					resource.TestCheckResourceAttr("data.influxdb-v2_ready.acctest", "status", "ready"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_ready.acctest", "health", "pass"),
+					resource.TestCheckResourceAttrSet("data.influxdb-v2_ready.acctest", "version"),
+					resource.TestCheckResourceAttrSet("data.influxdb-v2_ready.acctest", "started"),
 				),
 			},
 		},
@@ -29,5 +40,46 @@ func TestAccReady(t *testing.T) {
 
 func testAccReady() string {
 	return `
-data "influxdb-v2_ready" "acctest" {}`
+data "influxdb-v2_ready" "acctest" {
+    wait_timeout = "30s"
+}`
+}
+
+func TestWaitForReady(t *testing.T) {
+	var readyCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/ready":
+			// The server is starting for the first two checks
+			if atomic.AddInt32(&readyCalls, 1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, `{"code":"unavailable","message":"starting"}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"ready","started":"2024-01-02T03:04:05Z","up":"1m0s"}`)
+		case "/health":
+			fmt.Fprint(w, `{"name":"influxdb","status":"pass","version":"v2.7.1","commit":"abc","checks":[{"name":"bolt","status":"pass"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	influx := influxdb2.NewClient(server.URL, "")
+
+	_, _, err := waitForReady(context.Background(), influx, 0, time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a starting server to fail without a wait timeout")
+	}
+
+	ready, health, err := waitForReady(context.Background(), influx, 5*time.Second, time.Millisecond)
+	if err != nil {
+		t.Fatalf("err waiting for the server: %s", err)
+	}
+	if *ready.Up != "1m0s" || *health.Version != "v2.7.1" || len(*health.Checks) != 1 {
+		t.Fatalf("unexpected ready %v and health %v", ready, health)
+	}
+	if calls := atomic.LoadInt32(&readyCalls); calls != 3 {
+		t.Fatalf("expected /ready to be checked 3 times but got %d", calls)
+	}
 }
@@ -0,0 +1,129 @@
+package influxdbv2
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+func dataSourceScraper() *schema.Resource {
+	s := scraperDataSchema()
+	s["name"] = &schema.Schema{
+		Description: "Scraper name.",
+		Type:        schema.TypeString,
+		Required:    true,
+	}
+	s["org_id"] = &schema.Schema{
+		Description: "ID of the organization of the scraper, needed when scrapers of several organizations share its name.",
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+	}
+	return &schema.Resource{
+		Description: "Lookup a Scraper in InfluxDB2.",
+		ReadContext: dataSourceScraperRead,
+		Schema:      s,
+	}
+}
+
+// scraperDataSchema describes a scraper target read by the data sources.
+func scraperDataSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"id": {
+			Description: "Scraper id.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"name": {
+			Description: "Scraper name.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"org_id": {
+			Description: "ID of the organization of the scraper.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"bucket_id": {
+			Description: "ID of the bucket the scraped metrics are written to.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"url": {
+			Description: "URL of the scraped metrics endpoint.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"type": {
+			Description: "Type of the scraped metrics.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"allow_insecure": {
+			Description: "Whether the TLS certificate of the endpoint is verified.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+		},
+	}
+}
+
+func dataSourceScraperRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	influx, err := m.(*meta).client(nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	params := &domain.GetScrapersParams{Name: &name}
+	if orgID, ok := d.GetOk("org_id"); ok {
+		id := orgID.(string)
+		params.OrgID = &id
+	}
+	result, err := influx.APIClient().GetScrapers(ctx, params)
+	if err != nil {
+		return diag.Errorf("error getting scrapers: %v", err)
+	}
+	var scrapers []domain.ScraperTargetResponse
+	if result.Configurations != nil {
+		scrapers = *result.Configurations
+	}
+	switch len(scrapers) {
+	case 0:
+		return diag.Errorf("Can't find Scraper with name: %s", name)
+	case 1:
+	default:
+		return diag.Errorf("found %d scrapers named %s, set org_id to choose one", len(scrapers), name)
+	}
+
+	for key, value := range flattenScraper(scrapers[0]) {
+		err = d.Set(key, value)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	d.SetId(*scrapers[0].Id)
+	return nil
+}
+
+func flattenScraper(scraper domain.ScraperTargetResponse) map[string]interface{} {
+	targetType := ""
+	if scraper.Type != nil {
+		targetType = string(*scraper.Type)
+	}
+	// When insecure is set to false this pointer is nil
+	insecure := false
+	if scraper.AllowInsecure != nil {
+		insecure = *scraper.AllowInsecure
+	}
+	return map[string]interface{}{
+		"id":             stringValue(scraper.Id),
+		"name":           stringValue(scraper.Name),
+		"org_id":         stringValue(scraper.OrgID),
+		"bucket_id":      stringValue(scraper.BucketID),
+		"url":            stringValue(scraper.Url),
+		"type":           targetType,
+		"allow_insecure": insecure,
+	}
+}
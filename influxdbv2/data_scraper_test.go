@@ -0,0 +1,49 @@
+package influxdbv2
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// TestAccReadScraper tests the scraper and scrapers data sources
+func TestAccReadScraper(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testDataSourceScraperConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.influxdb-v2_scraper.by_name", "id", "influxdb-v2_scraper.scraper", "id"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_scraper.by_name", "org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+					resource.TestCheckResourceAttr("data.influxdb-v2_scraper.by_name", "url", "http://localhost:8086/metrics"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_scraper.by_name", "type", "prometheus"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_scraper.by_name", "allow_insecure", "true"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_scrapers.by_org", "scrapers.#", "1"),
+					resource.TestCheckResourceAttr("data.influxdb-v2_scrapers.by_org", "scrapers.0.name", "AcctestScraper"),
+				),
+			},
+		},
+	})
+}
+
+func testDataSourceScraperConfig() string {
+	return `resource "influxdb-v2_scraper" "scraper" {
+			name = "AcctestScraper"
+			org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+			bucket_id = "` + os.Getenv("INFLUXDB_V2_BUCKET_ID") + `"
+			allow_insecure = true
+			url = "http://localhost:8086/metrics"
+			}
+			data "influxdb-v2_scraper" "by_name" {
+				name = influxdb-v2_scraper.scraper.name
+				depends_on = [influxdb-v2_scraper.scraper]
+			}
+			data "influxdb-v2_scrapers" "by_org" {
+				org_id = influxdb-v2_scraper.scraper.org_id
+				depends_on = [influxdb-v2_scraper.scraper]
+			}
+`
+}
@@ -0,0 +1,72 @@
+package influxdbv2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+func dataSourceScrapers() *schema.Resource {
+	return &schema.Resource{
+		Description: "List the Scrapers of InfluxDB2.",
+		ReadContext: dataSourceScrapersRead,
+
+		Schema: map[string]*schema.Schema{
+			"org_id": {
+				Description: "Only list the scrapers of this organization.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"name": {
+				Description: "Only list the scrapers with this name.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"scrapers": {
+				Description: "Scrapers found.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: scraperDataSchema(),
+				},
+			},
+		},
+	}
+}
+
+func dataSourceScrapersRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	influx, err := m.(*meta).client(nil)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	params := &domain.GetScrapersParams{}
+	orgID := d.Get("org_id").(string)
+	if orgID != "" {
+		params.OrgID = &orgID
+	}
+	name := d.Get("name").(string)
+	if name != "" {
+		params.Name = &name
+	}
+	result, err := influx.APIClient().GetScrapers(ctx, params)
+	if err != nil {
+		return diag.Errorf("error getting scrapers: %v", err)
+	}
+
+	scrapers := []map[string]interface{}{}
+	if result.Configurations != nil {
+		for _, scraper := range *result.Configurations {
+			scrapers = append(scrapers, flattenScraper(scraper))
+		}
+	}
+	err = d.Set("scrapers", scrapers)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(strings.Join([]string{"scrapers", orgID, name}, "/"))
+	return nil
+}
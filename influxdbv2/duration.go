@@ -0,0 +1,120 @@
+package influxdbv2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Units of the durations accepted for retention, longest first
+var durationUnits = []struct {
+	name    string
+	seconds int64
+}{
+	{"w", 7 * 24 * 60 * 60},
+	{"d", 24 * 60 * 60},
+	{"h", 60 * 60},
+	{"m", 60},
+	{"s", 1},
+}
+
+// Units of Flux durations that retention can't be given in, with why
+var unsupportedDurationUnits = map[string]string{
+	"ms": "retention is in whole seconds",
+	"us": "retention is in whole seconds",
+	"µs": "retention is in whole seconds",
+	"ns": "retention is in whole seconds",
+	"mo": "months vary in length",
+	"y":  "years vary in length",
+}
+
+// parseDuration reads a Flux style duration such as 30d, 1w or 1h30m into
+// seconds. Go durations such as 720h0m0s are accepted too.
+func parseDuration(value string) (int64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if value == "0" {
+		return 0, nil
+	}
+	var total int64
+	rest := value
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		rest = rest[i:]
+		j := 0
+		for j < len(rest) && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+		unit := rest[:j]
+		rest = rest[j:]
+		found := false
+		for _, u := range durationUnits {
+			if u.name == unit {
+				total += n * u.seconds
+				found = true
+				break
+			}
+		}
+		if reason, ok := unsupportedDurationUnits[unit]; ok {
+			return 0, fmt.Errorf("unsupported unit %q in duration %q as %s, expected one of w, d, h, m or s", unit, value, reason)
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid unit %q in duration %q, expected one of w, d, h, m or s", unit, value)
+		}
+	}
+	return total, nil
+}
+
+// formatDuration writes seconds as the shortest duration that reads well, such
+// as 30d rather than 4w2d.
+func formatDuration(seconds int64) string {
+	if seconds == 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	for _, u := range durationUnits {
+		// Weeks are only used for whole weeks
+		if u.name == "w" && seconds%u.seconds != 0 {
+			continue
+		}
+		if seconds >= u.seconds {
+			fmt.Fprintf(&b, "%d%s", seconds/u.seconds, u.name)
+			seconds %= u.seconds
+		}
+	}
+	return b.String()
+}
+
+func validateRetentionDuration(v interface{}, k string) (ws []string, es []error) {
+	if _, err := parseDuration(v.(string)); err != nil {
+		es = append(es, fmt.Errorf("expected %s to be a duration such as 30d or 1w: %s", k, err))
+	}
+	return
+}
+
+// suppressEquivalentDuration ignores a duration written differently, such as
+// 168h instead of 1w.
+func suppressEquivalentDuration(k, old, new string, d *schema.ResourceData) bool {
+	if old == "" || new == "" {
+		return false
+	}
+	oldSeconds, err := parseDuration(old)
+	if err != nil {
+		return false
+	}
+	newSeconds, err := parseDuration(new)
+	return err == nil && oldSeconds == newSeconds
+}
@@ -0,0 +1,59 @@
+package influxdbv2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]int64{
+		"0":        0,
+		"0s":       0,
+		"30d":      30 * 24 * 60 * 60,
+		"1w":       7 * 24 * 60 * 60,
+		"1h30m":    90 * 60,
+		"720h0m0s": 30 * 24 * 60 * 60,
+	}
+	for value, want := range cases {
+		seconds, err := parseDuration(value)
+		if err != nil {
+			t.Errorf("err parsing %q: %s", value, err)
+			continue
+		}
+		if seconds != want {
+			t.Errorf("expected %q to be %d seconds but got %d", value, want, seconds)
+		}
+	}
+	for _, value := range []string{"", "d", "30", "1mo", "1y", "1.5h", "-1d", "1500ms", "1us", "1µs", "1ns", "1s500ms"} {
+		if _, err := parseDuration(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+	if _, err := parseDuration("1500ms"); err == nil || !strings.Contains(err.Error(), "whole seconds") {
+		t.Errorf("expected sub-second units to be rejected as such, got: %v", err)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[int64]string{
+		0:       "0s",
+		3640:    "1h40s",
+		86400:   "1d",
+		604800:  "1w",
+		2592000: "30d",
+		1209600: "2w",
+	}
+	for seconds, want := range cases {
+		if value := formatDuration(seconds); value != want {
+			t.Errorf("expected %d seconds to be %q but got %q", seconds, want, value)
+		}
+	}
+}
+
+func TestHashRetentionRule(t *testing.T) {
+	seconds := map[string]interface{}{"every_seconds": 2592000, "shard_group_duration_seconds": 86400, "type": "expire"}
+	durations := map[string]interface{}{"every": "30d", "shard_group_duration": "1d", "type": "expire"}
+	if hashRetentionRule(seconds) != hashRetentionRule(durations) {
+		t.Fatal("expected a rule to hash the same in seconds and as durations")
+	}
+}
@@ -0,0 +1,1579 @@
+package influxdbv2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
+)
+
+// fakeInfluxDB is an in-memory stand-in for InfluxDB OSS 2.7, so that
+// resources can be tested with go test alone. It serves the endpoints that the
+// provider uses with the status codes and error bodies of the real server, and
+// requires the token of an active authorization or a session on every API call
+// but setup and sign in.
+type fakeInfluxDB struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	lastID  uint64
+	started time.Time
+	// Whether /health answers as InfluxDB Cloud
+	cloud bool
+
+	users          map[string]*domain.UserResponse
+	passwords      map[string]string
+	sessions       map[string]string
+	orgs           map[string]*domain.Organization
+	owners         map[string][]string
+	buckets        map[string]*domain.Bucket
+	authorizations map[string]*domain.Authorization
+	dbrps          map[string]*domain.DBRP
+	scrapers       map[string]*domain.ScraperTargetResponse
+	// Authorizations of the v1 compatibility API and their passwords
+	legacyAuthorizations map[string]*Authorization
+	legacyPasswords      map[string]string
+	// How many times a legacy password was set, as it can't be read back
+	legacyPasswordPosts int
+}
+
+// fakeError is an error answered by the fake server.
+type fakeError struct {
+	status  int
+	code    domain.ErrorCode
+	message string
+}
+
+func newFakeError(status int, code domain.ErrorCode, format string, args ...interface{}) *fakeError {
+	return &fakeError{status: status, code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func (e *fakeError) write(w http.ResponseWriter) {
+	writeFakeJSON(w, e.status, map[string]string{"code": string(e.code), "message": e.message})
+}
+
+// fakeHandler serves an API call made by the user of a valid token.
+type fakeHandler func(w http.ResponseWriter, r *http.Request, userID string)
+
+// fakeResourceTypes are the types of resource that permissions can be given
+// on, as listed by /api/v2/resources.
+var fakeResourceTypes = []domain.ResourceType{
+	domain.ResourceTypeAuthorizations,
+	domain.ResourceTypeBuckets,
+	domain.ResourceTypeDashboards,
+	domain.ResourceTypeOrgs,
+	domain.ResourceTypeSources,
+	domain.ResourceTypeTasks,
+	domain.ResourceTypeTelegrafs,
+	domain.ResourceTypeUsers,
+	domain.ResourceTypeVariables,
+	domain.ResourceTypeScrapers,
+	domain.ResourceTypeSecrets,
+	domain.ResourceTypeLabels,
+	domain.ResourceTypeViews,
+	domain.ResourceTypeDocuments,
+	domain.ResourceTypeNotificationRules,
+	domain.ResourceTypeNotificationEndpoints,
+	domain.ResourceTypeChecks,
+	domain.ResourceTypeDbrp,
+	domain.ResourceTypeNotebooks,
+	domain.ResourceTypeAnnotations,
+	domain.ResourceTypeRemotes,
+	domain.ResourceTypeReplications,
+	domain.ResourceTypeInstance,
+}
+
+// newFakeInfluxDB starts a fake server that still has to be set up.
+func newFakeInfluxDB() *fakeInfluxDB {
+	f := &fakeInfluxDB{
+		started:              time.Now().UTC(),
+		users:                map[string]*domain.UserResponse{},
+		passwords:            map[string]string{},
+		sessions:             map[string]string{},
+		orgs:                 map[string]*domain.Organization{},
+		owners:               map[string][]string{},
+		buckets:              map[string]*domain.Bucket{},
+		authorizations:       map[string]*domain.Authorization{},
+		dbrps:                map[string]*domain.DBRP{},
+		scrapers:             map[string]*domain.ScraperTargetResponse{},
+		legacyAuthorizations: map[string]*Authorization{},
+		legacyPasswords:      map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", f.health)
+	mux.HandleFunc("GET /ping", f.ping)
+	mux.HandleFunc("GET /ready", f.ready)
+	mux.HandleFunc("GET /api/v2/setup", f.getSetup)
+	mux.HandleFunc("POST /api/v2/setup", f.postSetup)
+	mux.HandleFunc("POST /api/v2/signin", f.signIn)
+	mux.HandleFunc("POST /api/v2/signout", f.authorized(f.signOut))
+	mux.HandleFunc("GET /api/v2/resources", f.authorized(f.getResources))
+
+	mux.HandleFunc("GET /api/v2/orgs", f.authorized(f.getOrgs))
+	mux.HandleFunc("POST /api/v2/orgs", f.authorized(f.postOrg))
+	mux.HandleFunc("GET /api/v2/orgs/{id}", f.authorized(f.getOrg))
+	mux.HandleFunc("PATCH /api/v2/orgs/{id}", f.authorized(f.patchOrg))
+	mux.HandleFunc("DELETE /api/v2/orgs/{id}", f.authorized(f.deleteOrg))
+	mux.HandleFunc("GET /api/v2/orgs/{id}/members", f.authorized(f.getOrgMembers))
+	mux.HandleFunc("GET /api/v2/orgs/{id}/owners", f.authorized(f.getOrgOwners))
+
+	mux.HandleFunc("GET /api/v2/buckets", f.authorized(f.getBuckets))
+	mux.HandleFunc("POST /api/v2/buckets", f.authorized(f.postBucket))
+	mux.HandleFunc("GET /api/v2/buckets/{id}", f.authorized(f.getBucket))
+	mux.HandleFunc("PATCH /api/v2/buckets/{id}", f.authorized(f.patchBucket))
+	mux.HandleFunc("DELETE /api/v2/buckets/{id}", f.authorized(f.deleteBucket))
+
+	mux.HandleFunc("GET /api/v2/authorizations", f.authorized(f.getAuthorizations))
+	mux.HandleFunc("POST /api/v2/authorizations", f.authorized(f.postAuthorization))
+	mux.HandleFunc("GET /api/v2/authorizations/{id}", f.authorized(f.getAuthorization))
+	mux.HandleFunc("PATCH /api/v2/authorizations/{id}", f.authorized(f.patchAuthorization))
+	mux.HandleFunc("DELETE /api/v2/authorizations/{id}", f.authorized(f.deleteAuthorization))
+
+	mux.HandleFunc("GET /private/legacy/authorizations", f.authorized(f.getLegacyAuthorizations))
+	mux.HandleFunc("POST /private/legacy/authorizations", f.authorized(f.postLegacyAuthorization))
+	mux.HandleFunc("GET /private/legacy/authorizations/{id}", f.authorized(f.getLegacyAuthorization))
+	mux.HandleFunc("PATCH /private/legacy/authorizations/{id}", f.authorized(f.patchLegacyAuthorization))
+	mux.HandleFunc("DELETE /private/legacy/authorizations/{id}", f.authorized(f.deleteLegacyAuthorization))
+	mux.HandleFunc("POST /private/legacy/authorizations/{id}/password", f.authorized(f.postLegacyPassword))
+
+	mux.HandleFunc("GET /api/v2/dbrps", f.authorized(f.getDBRPs))
+	mux.HandleFunc("POST /api/v2/dbrps", f.authorized(f.postDBRP))
+	mux.HandleFunc("GET /api/v2/dbrps/{id}", f.authorized(f.getDBRP))
+	mux.HandleFunc("PATCH /api/v2/dbrps/{id}", f.authorized(f.patchDBRP))
+	mux.HandleFunc("DELETE /api/v2/dbrps/{id}", f.authorized(f.deleteDBRP))
+
+	mux.HandleFunc("GET /api/v2/scrapers", f.authorized(f.getScrapers))
+	mux.HandleFunc("POST /api/v2/scrapers", f.authorized(f.postScraper))
+	mux.HandleFunc("GET /api/v2/scrapers/{id}", f.authorized(f.getScraper))
+	mux.HandleFunc("PATCH /api/v2/scrapers/{id}", f.authorized(f.patchScraper))
+	mux.HandleFunc("DELETE /api/v2/scrapers/{id}", f.authorized(f.deleteScraper))
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "path not found").write(w)
+	})
+
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// setup onboards the server as scripts/setup_influxdb.sh does.
+func (f *fakeInfluxDB) setup() (*domain.OnboardingResponse, error) {
+	influx := influxdb2.NewClient(f.URL, "")
+	defer influx.Close()
+	return influx.SetupWithToken(context.Background(), "admin", "password", "testorg", "testbucket", 0, "")
+}
+
+func (f *fakeInfluxDB) authorized(next fakeHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if cookie, err := r.Cookie(fakeSessionCookie); err == nil {
+			if userID, ok := f.sessions[cookie.Value]; ok {
+				next(w, r, userID)
+				return
+			}
+		}
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(strings.TrimPrefix(header, "Token "), "Bearer ")
+		if token != "" && token != header {
+			for _, authorization := range f.authorizations {
+				if *authorization.Token == token && *authorization.Status == domain.AuthorizationUpdateRequestStatusActive {
+					next(w, r, *authorization.UserID)
+					return
+				}
+			}
+		}
+		newFakeError(http.StatusUnauthorized, domain.ErrorCodeUnauthorized, "unauthorized access").write(w)
+	}
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func decodeFakeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "failed to decode request body: %s", err).write(w)
+		return false
+	}
+	return true
+}
+
+// newID returns an ID of 16 hexadecimal digits like the server's.
+func (f *fakeInfluxDB) newID() string {
+	f.lastID++
+	return fmt.Sprintf("0a%014x", f.lastID)
+}
+
+func fakeToken(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
+// sortedFakeIDs returns the keys of a store in the order the server lists
+// them.
+func sortedFakeIDs[T any](store map[string]*T) []string {
+	ids := make([]string, 0, len(store))
+	for id := range store {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// fakePage applies the limit and offset of a list query to n items.
+func fakePage(r *http.Request, n int) (int, int) {
+	start, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if start > n {
+		start = n
+	}
+	end := start + limit
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func (f *fakeInfluxDB) health(w http.ResponseWriter, r *http.Request) {
+	name, version, commit := "influxdb", "v2.7.1", "407fa622e9"
+	if f.cloud {
+		name = "cloud2"
+	}
+	writeFakeJSON(w, http.StatusOK, domain.HealthCheck{
+		Name:    name,
+		Status:  domain.HealthCheckStatusPass,
+		Version: &version,
+		Commit:  &commit,
+		Checks:  &[]domain.HealthCheck{},
+	})
+}
+
+func (f *fakeInfluxDB) ping(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Influxdb-Build", "OSS")
+	w.Header().Set("X-Influxdb-Version", "v2.7.1")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) ready(w http.ResponseWriter, r *http.Request) {
+	status := domain.ReadyStatusReady
+	up := time.Since(f.started).Round(time.Millisecond).String()
+	writeFakeJSON(w, http.StatusOK, domain.Ready{Status: &status, Started: &f.started, Up: &up})
+}
+
+func (f *fakeInfluxDB) getSetup(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	writeFakeJSON(w, http.StatusOK, map[string]bool{"allowed": len(f.users) == 0})
+}
+
+// postSetup onboards the server: it creates the first user, organization and
+// bucket, and an operator token that can do anything.
+func (f *fakeInfluxDB) postSetup(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.users) > 0 {
+		newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "onboarding has already been completed").write(w)
+		return
+	}
+	var request domain.OnboardingRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.Username == "" || request.Org == "" || request.Bucket == "" {
+		newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeUnprocessableEntity, "username, org and bucket are required").write(w)
+		return
+	}
+	if request.Password != nil && len(*request.Password) < 8 {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "passwords must be at least 8 characters long").write(w)
+		return
+	}
+
+	userID := f.newID()
+	status := domain.UserResponseStatusActive
+	f.users[userID] = &domain.UserResponse{Id: &userID, Name: request.Username, Status: &status}
+	if request.Password != nil {
+		f.passwords[userID] = *request.Password
+	}
+	org := f.createOrg(request.Org, "", userID)
+	retention := int64(0)
+	if request.RetentionPeriodSeconds != nil {
+		retention = *request.RetentionPeriodSeconds
+	} else if request.RetentionPeriodHrs != nil {
+		retention = int64(*request.RetentionPeriodHrs) * 3600
+	}
+	bucket, err := f.createBucket(domain.PostBucketRequest{
+		Name:           request.Bucket,
+		OrgID:          *org.Id,
+		RetentionRules: &domain.RetentionRules{{EverySeconds: retention}},
+	}, domain.BucketTypeUser)
+	if err != nil {
+		err.write(w)
+		return
+	}
+
+	var permissions []domain.Permission
+	for _, resourceType := range fakeResourceTypes {
+		for _, action := range []domain.PermissionAction{domain.PermissionActionRead, domain.PermissionActionWrite} {
+			permissions = append(permissions, domain.Permission{Action: action, Resource: domain.Resource{Type: resourceType}})
+		}
+	}
+	description := fmt.Sprintf("%s's Token", request.Username)
+	authorization := f.createAuthorization(domain.AuthorizationPostRequest{
+		AuthorizationUpdateRequest: domain.AuthorizationUpdateRequest{Description: &description},
+		OrgID:                      org.Id,
+		Permissions:                &permissions,
+	}, userID)
+	if request.Token != nil && *request.Token != "" {
+		authorization.Token = request.Token
+	}
+
+	writeFakeJSON(w, http.StatusCreated, domain.OnboardingResponse{
+		User:   f.users[userID],
+		Org:    org,
+		Bucket: bucket,
+		Auth:   authorization,
+	})
+}
+
+const fakeSessionCookie = "influxdb-oss-session"
+
+// signIn opens a session for the user of the basic credentials.
+func (f *fakeInfluxDB) signIn(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	username, password, ok := r.BasicAuth()
+	if ok {
+		for id, user := range f.users {
+			if user.Name == username && f.passwords[id] == password {
+				session := fakeToken(f.newID())
+				f.sessions[session] = id
+				http.SetCookie(w, &http.Cookie{Name: fakeSessionCookie, Value: session, Path: "/api/"})
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+	}
+	newFakeError(http.StatusUnauthorized, domain.ErrorCodeUnauthorized, "unauthorized access").write(w)
+}
+
+func (f *fakeInfluxDB) signOut(w http.ResponseWriter, r *http.Request, userID string) {
+	if cookie, err := r.Cookie(fakeSessionCookie); err == nil {
+		delete(f.sessions, cookie.Value)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) getResources(w http.ResponseWriter, r *http.Request, userID string) {
+	writeFakeJSON(w, http.StatusOK, fakeResourceTypes)
+}
+
+// createOrg creates an organization owned by a user, with the system buckets
+// of the server.
+func (f *fakeInfluxDB) createOrg(name, description, ownerID string) *domain.Organization {
+	id := f.newID()
+	now := time.Now().UTC()
+	status := domain.OrganizationStatusActive
+	org := &domain.Organization{Id: &id, Name: name, Description: &description, Status: &status, CreatedAt: &now, UpdatedAt: &now}
+	f.orgs[id] = org
+	f.owners[id] = []string{ownerID}
+	for bucket, retention := range map[string]int64{"_monitoring": 7 * 24 * 3600, "_tasks": 3 * 24 * 3600} {
+		_, _ = f.createBucket(domain.PostBucketRequest{
+			Name:           bucket,
+			OrgID:          id,
+			RetentionRules: &domain.RetentionRules{{EverySeconds: retention}},
+		}, domain.BucketTypeSystem)
+	}
+	return org
+}
+
+func (f *fakeInfluxDB) findOrg(id string) (*domain.Organization, *fakeError) {
+	org, ok := f.orgs[id]
+	if !ok {
+		return nil, newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "organization not found")
+	}
+	return org, nil
+}
+
+func (f *fakeInfluxDB) findOrgByName(name string) *domain.Organization {
+	for _, org := range f.orgs {
+		if org.Name == name {
+			return org
+		}
+	}
+	return nil
+}
+
+func (f *fakeInfluxDB) getOrgs(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+	var orgs []domain.Organization
+	for _, id := range sortedFakeIDs(f.orgs) {
+		org := f.orgs[id]
+		if (query.Has("org") && org.Name != query.Get("org")) || (query.Has("orgID") && id != query.Get("orgID")) {
+			continue
+		}
+		orgs = append(orgs, *org)
+	}
+	if len(orgs) == 0 && query.Has("org") {
+		newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "organization name %q not found", query.Get("org")).write(w)
+		return
+	}
+	start, end := fakePage(r, len(orgs))
+	orgs = append([]domain.Organization{}, orgs[start:end]...)
+	writeFakeJSON(w, http.StatusOK, domain.Organizations{Orgs: &orgs})
+}
+
+func (f *fakeInfluxDB) postOrg(w http.ResponseWriter, r *http.Request, userID string) {
+	var request domain.PostOrganizationRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.Name == "" {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "org name is empty").write(w)
+		return
+	}
+	if f.findOrgByName(request.Name) != nil {
+		newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "organization with name %s already exists", request.Name).write(w)
+		return
+	}
+	description := ""
+	if request.Description != nil {
+		description = *request.Description
+	}
+	writeFakeJSON(w, http.StatusCreated, f.createOrg(request.Name, description, userID))
+}
+
+func (f *fakeInfluxDB) getOrg(w http.ResponseWriter, r *http.Request, userID string) {
+	org, err := f.findOrg(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, org)
+}
+
+func (f *fakeInfluxDB) patchOrg(w http.ResponseWriter, r *http.Request, userID string) {
+	org, err := f.findOrg(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	var request domain.PatchOrganizationRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.Name != nil && *request.Name != org.Name {
+		if *request.Name == "" {
+			newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "org name is empty").write(w)
+			return
+		}
+		if f.findOrgByName(*request.Name) != nil {
+			newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "organization with name %s already exists", *request.Name).write(w)
+			return
+		}
+		org.Name = *request.Name
+	}
+	if request.Description != nil {
+		org.Description = request.Description
+	}
+	now := time.Now().UTC()
+	org.UpdatedAt = &now
+	writeFakeJSON(w, http.StatusOK, org)
+}
+
+// deleteOrg deletes an organization with everything in it.
+func (f *fakeInfluxDB) deleteOrg(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	if _, err := f.findOrg(id); err != nil {
+		err.write(w)
+		return
+	}
+	delete(f.orgs, id)
+	delete(f.owners, id)
+	for bucketID, bucket := range f.buckets {
+		if *bucket.OrgID == id {
+			delete(f.buckets, bucketID)
+		}
+	}
+	for authorizationID, authorization := range f.authorizations {
+		if *authorization.OrgID == id {
+			delete(f.authorizations, authorizationID)
+		}
+	}
+	for authorizationID, authorization := range f.legacyAuthorizations {
+		if *authorization.OrgID == id {
+			delete(f.legacyAuthorizations, authorizationID)
+			delete(f.legacyPasswords, authorizationID)
+		}
+	}
+	for dbrpID, dbrp := range f.dbrps {
+		if dbrp.OrgID == id {
+			delete(f.dbrps, dbrpID)
+		}
+	}
+	for scraperID, scraper := range f.scrapers {
+		if *scraper.OrgID == id {
+			delete(f.scrapers, scraperID)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) getOrgMembers(w http.ResponseWriter, r *http.Request, userID string) {
+	if _, err := f.findOrg(r.PathValue("id")); err != nil {
+		err.write(w)
+		return
+	}
+	// Owners are not listed as members
+	writeFakeJSON(w, http.StatusOK, domain.ResourceMembers{Users: &[]domain.ResourceMember{}})
+}
+
+func (f *fakeInfluxDB) getOrgOwners(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	if _, err := f.findOrg(id); err != nil {
+		err.write(w)
+		return
+	}
+	role := domain.ResourceOwnerRoleOwner
+	owners := []domain.ResourceOwner{}
+	for _, ownerID := range f.owners[id] {
+		owners = append(owners, domain.ResourceOwner{UserResponse: *f.users[ownerID], Role: &role})
+	}
+	writeFakeJSON(w, http.StatusOK, domain.ResourceOwners{Users: &owners})
+}
+
+// createBucket creates a bucket with the retention rule of the request, whose
+// shard group duration defaults to the server's.
+func (f *fakeInfluxDB) createBucket(request domain.PostBucketRequest, bucketType domain.BucketType) (*domain.Bucket, *fakeError) {
+	if request.Name == "" {
+		return nil, newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "bucket name is required")
+	}
+	if strings.HasPrefix(request.Name, "_") && bucketType != domain.BucketTypeSystem {
+		return nil, newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "bucket name %s is invalid. Buckets may not start with underscore", request.Name)
+	}
+	if _, err := f.findOrg(request.OrgID); err != nil {
+		return nil, err
+	}
+	if f.findBucketByName(request.OrgID, request.Name) != nil {
+		return nil, newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "bucket with name %s already exists", request.Name)
+	}
+	var rules domain.RetentionRules
+	if request.RetentionRules != nil {
+		rules = *request.RetentionRules
+	}
+	rules, err := fakeRetentionRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	id := f.newID()
+	now := time.Now().UTC()
+	bucket := &domain.Bucket{
+		Id:             &id,
+		Name:           request.Name,
+		Description:    request.Description,
+		OrgID:          &request.OrgID,
+		RetentionRules: rules,
+		Rp:             request.Rp,
+		Type:           &bucketType,
+		CreatedAt:      &now,
+		UpdatedAt:      &now,
+	}
+	f.buckets[id] = bucket
+	return bucket, nil
+}
+
+// fakeRetentionRules checks the retention rule of a bucket and sets the
+// default shard group duration, which is the one the provider expects.
+func fakeRetentionRules(rules domain.RetentionRules) (domain.RetentionRules, *fakeError) {
+	if len(rules) == 0 {
+		rules = domain.RetentionRules{{EverySeconds: 0}}
+	}
+	if len(rules) > 1 {
+		return nil, newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "expected at most one retention rule")
+	}
+	rule := rules[0]
+	if rule.EverySeconds != 0 && rule.EverySeconds < 3600 {
+		return nil, newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "expiration seconds must be greater than or equal to one hour")
+	}
+	shard := getDefaultShardGroupDuration(rule.EverySeconds)
+	if rule.ShardGroupDurationSeconds != nil && *rule.ShardGroupDurationSeconds > 0 {
+		shard = *rule.ShardGroupDurationSeconds
+	}
+	if rule.EverySeconds != 0 && shard > rule.EverySeconds {
+		return nil, newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "shard-group duration must also be less than or equal to bucket retention")
+	}
+	expire := domain.RetentionRuleTypeExpire
+	return domain.RetentionRules{{EverySeconds: rule.EverySeconds, ShardGroupDurationSeconds: &shard, Type: &expire}}, nil
+}
+
+func (f *fakeInfluxDB) findBucket(id string) (*domain.Bucket, *fakeError) {
+	bucket, ok := f.buckets[id]
+	if !ok {
+		return nil, newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "bucket not found")
+	}
+	return bucket, nil
+}
+
+func (f *fakeInfluxDB) findBucketByName(orgID, name string) *domain.Bucket {
+	for _, bucket := range f.buckets {
+		if *bucket.OrgID == orgID && bucket.Name == name {
+			return bucket
+		}
+	}
+	return nil
+}
+
+func (f *fakeInfluxDB) getBuckets(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+	orgID := query.Get("orgID")
+	if query.Has("org") {
+		org := f.findOrgByName(query.Get("org"))
+		if org == nil {
+			newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "organization name %q not found", query.Get("org")).write(w)
+			return
+		}
+		orgID = *org.Id
+	}
+	var buckets []domain.Bucket
+	for _, id := range sortedFakeIDs(f.buckets) {
+		bucket := f.buckets[id]
+		if (orgID != "" && *bucket.OrgID != orgID) || (query.Has("name") && bucket.Name != query.Get("name")) || (query.Has("id") && id != query.Get("id")) {
+			continue
+		}
+		buckets = append(buckets, *bucket)
+	}
+	start, end := fakePage(r, len(buckets))
+	buckets = append([]domain.Bucket{}, buckets[start:end]...)
+	writeFakeJSON(w, http.StatusOK, domain.Buckets{Buckets: &buckets})
+}
+
+func (f *fakeInfluxDB) postBucket(w http.ResponseWriter, r *http.Request, userID string) {
+	var request domain.PostBucketRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	bucket, err := f.createBucket(request, domain.BucketTypeUser)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusCreated, bucket)
+}
+
+func (f *fakeInfluxDB) getBucket(w http.ResponseWriter, r *http.Request, userID string) {
+	bucket, err := f.findBucket(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, bucket)
+}
+
+func (f *fakeInfluxDB) patchBucket(w http.ResponseWriter, r *http.Request, userID string) {
+	bucket, err := f.findBucket(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	var request domain.PatchBucketRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.Name != nil && *request.Name != bucket.Name {
+		if *bucket.Type == domain.BucketTypeSystem {
+			newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "system buckets cannot be renamed").write(w)
+			return
+		}
+		if f.findBucketByName(*bucket.OrgID, *request.Name) != nil {
+			newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "bucket with name %s already exists", *request.Name).write(w)
+			return
+		}
+	}
+	rules := bucket.RetentionRules
+	if request.RetentionRules != nil {
+		rules = domain.RetentionRules{}
+		for _, rule := range *request.RetentionRules {
+			rules = append(rules, domain.RetentionRule{EverySeconds: rule.EverySeconds, ShardGroupDurationSeconds: rule.ShardGroupDurationSeconds})
+		}
+		rules, err = fakeRetentionRules(rules)
+		if err != nil {
+			err.write(w)
+			return
+		}
+	}
+	if request.Name != nil {
+		bucket.Name = *request.Name
+	}
+	if request.Description != nil {
+		bucket.Description = request.Description
+	}
+	bucket.RetentionRules = rules
+	now := time.Now().UTC()
+	bucket.UpdatedAt = &now
+	writeFakeJSON(w, http.StatusOK, bucket)
+}
+
+// deleteBucket deletes a bucket with its dbrp mappings.
+func (f *fakeInfluxDB) deleteBucket(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	bucket, err := f.findBucket(id)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	if *bucket.Type == domain.BucketTypeSystem {
+		newFakeError(http.StatusForbidden, domain.ErrorCodeForbidden, "system buckets cannot be deleted").write(w)
+		return
+	}
+	delete(f.buckets, id)
+	for dbrpID, dbrp := range f.dbrps {
+		if dbrp.BucketID == id {
+			delete(f.dbrps, dbrpID)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkPermissions checks that permissions can be given by a token. Like the
+// server, it refuses ids that are given but empty rather than ignoring them.
+func (f *fakeInfluxDB) checkPermissions(actions []string, types []string, ids []*string, orgIDs []*string) *fakeError {
+	if len(actions) == 0 {
+		return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "authorization must have at least one permission")
+	}
+	for i := range actions {
+		if actions[i] != string(domain.PermissionActionRead) && actions[i] != string(domain.PermissionActionWrite) {
+			return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "unknown action for permission")
+		}
+		known := false
+		for _, resourceType := range fakeResourceTypes {
+			known = known || types[i] == string(resourceType)
+		}
+		if !known {
+			return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "unknown resource type for permission")
+		}
+		for _, id := range []*string{ids[i], orgIDs[i]} {
+			if id != nil && len(*id) != 16 {
+				return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "id must have a length of 16 bytes")
+			}
+		}
+		if orgIDs[i] != nil {
+			if _, err := f.findOrg(*orgIDs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeInfluxDB) createAuthorization(request domain.AuthorizationPostRequest, userID string) *domain.Authorization {
+	id := f.newID()
+	token := fakeToken(id)
+	now := time.Now().UTC()
+	status := domain.AuthorizationUpdateRequestStatusActive
+	if request.Status != nil {
+		status = *request.Status
+	}
+	if request.UserID != nil && *request.UserID != "" {
+		userID = *request.UserID
+	}
+	org := f.orgs[*request.OrgID]
+	user := f.users[userID]
+	authorization := &domain.Authorization{
+		AuthorizationUpdateRequest: domain.AuthorizationUpdateRequest{Description: request.Description, Status: &status},
+		Id:                         &id,
+		Token:                      &token,
+		OrgID:                      request.OrgID,
+		Org:                        &org.Name,
+		UserID:                     &userID,
+		User:                       &user.Name,
+		Permissions:                request.Permissions,
+		CreatedAt:                  &now,
+		UpdatedAt:                  &now,
+	}
+	f.authorizations[id] = authorization
+	return authorization
+}
+
+func (f *fakeInfluxDB) findAuthorization(id string) (*domain.Authorization, *fakeError) {
+	authorization, ok := f.authorizations[id]
+	if !ok {
+		return nil, newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "authorization not found")
+	}
+	return authorization, nil
+}
+
+func (f *fakeInfluxDB) getAuthorizations(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+	authorizations := []domain.Authorization{}
+	for _, id := range sortedFakeIDs(f.authorizations) {
+		authorization := f.authorizations[id]
+		if (query.Has("orgID") && *authorization.OrgID != query.Get("orgID")) ||
+			(query.Has("org") && *authorization.Org != query.Get("org")) ||
+			(query.Has("userID") && *authorization.UserID != query.Get("userID")) ||
+			(query.Has("user") && *authorization.User != query.Get("user")) ||
+			(query.Has("token") && *authorization.Token != query.Get("token")) {
+			continue
+		}
+		authorizations = append(authorizations, *authorization)
+	}
+	writeFakeJSON(w, http.StatusOK, domain.Authorizations{Authorizations: &authorizations})
+}
+
+func (f *fakeInfluxDB) postAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	var request domain.AuthorizationPostRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.OrgID == nil || *request.OrgID == "" {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "org is required").write(w)
+		return
+	}
+	if _, err := f.findOrg(*request.OrgID); err != nil {
+		err.write(w)
+		return
+	}
+	var actions, types []string
+	var ids, orgIDs []*string
+	if request.Permissions != nil {
+		for _, permission := range *request.Permissions {
+			actions = append(actions, string(permission.Action))
+			types = append(types, string(permission.Resource.Type))
+			ids = append(ids, permission.Resource.Id)
+			orgIDs = append(orgIDs, permission.Resource.OrgID)
+		}
+	}
+	if err := f.checkPermissions(actions, types, ids, orgIDs); err != nil {
+		err.write(w)
+		return
+	}
+	if request.Status != nil && *request.Status != domain.AuthorizationUpdateRequestStatusActive && *request.Status != domain.AuthorizationUpdateRequestStatusInactive {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "status must be active or inactive").write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusCreated, f.createAuthorization(request, userID))
+}
+
+func (f *fakeInfluxDB) getAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	authorization, err := f.findAuthorization(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, authorization)
+}
+
+func (f *fakeInfluxDB) patchAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	authorization, err := f.findAuthorization(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	var request domain.AuthorizationUpdateRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.Status != nil {
+		if *request.Status != domain.AuthorizationUpdateRequestStatusActive && *request.Status != domain.AuthorizationUpdateRequestStatusInactive {
+			newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "status must be active or inactive").write(w)
+			return
+		}
+		authorization.Status = request.Status
+	}
+	if request.Description != nil {
+		authorization.Description = request.Description
+	}
+	now := time.Now().UTC()
+	authorization.UpdatedAt = &now
+	writeFakeJSON(w, http.StatusOK, authorization)
+}
+
+func (f *fakeInfluxDB) deleteAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	if _, err := f.findAuthorization(id); err != nil {
+		err.write(w)
+		return
+	}
+	delete(f.authorizations, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) findLegacyAuthorization(id string) (*Authorization, *fakeError) {
+	authorization, ok := f.legacyAuthorizations[id]
+	if !ok {
+		return nil, newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "authorization not found")
+	}
+	return authorization, nil
+}
+
+func (f *fakeInfluxDB) getLegacyAuthorizations(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+	authorizations := []Authorization{}
+	for _, id := range sortedFakeIDs(f.legacyAuthorizations) {
+		authorization := f.legacyAuthorizations[id]
+		if (query.Has("orgID") && *authorization.OrgID != query.Get("orgID")) ||
+			(query.Has("userID") && *authorization.UserID != query.Get("userID")) ||
+			(query.Has("token") && *authorization.Token != query.Get("token")) ||
+			(query.Has("authID") && id != query.Get("authID")) {
+			continue
+		}
+		authorizations = append(authorizations, *authorization)
+	}
+	writeFakeJSON(w, http.StatusOK, map[string]interface{}{"authorizations": authorizations})
+}
+
+// postLegacyAuthorization creates an authorization of the v1 API, whose token
+// is the username of v1 clients. It has no password until one is set.
+func (f *fakeInfluxDB) postLegacyAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	var request LegacyAuthorizationPostRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.OrgID == nil || *request.OrgID == "" {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "org is required").write(w)
+		return
+	}
+	org, err := f.findOrg(*request.OrgID)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	if request.Token == nil || *request.Token == "" {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "token is required").write(w)
+		return
+	}
+	for _, authorization := range f.legacyAuthorizations {
+		if *authorization.Token == *request.Token {
+			newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "token already exists").write(w)
+			return
+		}
+	}
+	var actions, types []string
+	var ids, orgIDs []*string
+	if request.Permissions != nil {
+		for _, permission := range *request.Permissions {
+			actions = append(actions, string(permission.Action))
+			types = append(types, string(permission.Resource.Type))
+			ids = append(ids, permission.Resource.Id)
+			orgIDs = append(orgIDs, permission.Resource.OrgID)
+		}
+	}
+	if err := f.checkPermissions(actions, types, ids, orgIDs); err != nil {
+		err.write(w)
+		return
+	}
+
+	id := f.newID()
+	now := time.Now().UTC()
+	status := AuthorizationStatusActive
+	if request.Status != nil {
+		status = AuthorizationStatus(*request.Status)
+	}
+	if request.UserID != nil && *request.UserID != "" {
+		userID = *request.UserID
+	}
+	user := f.users[userID]
+	authorization := &Authorization{
+		Id:          &id,
+		Token:       request.Token,
+		Description: request.Description,
+		Status:      &status,
+		OrgID:       request.OrgID,
+		Org:         &org.Name,
+		UserID:      &userID,
+		User:        &user.Name,
+		Permissions: request.Permissions,
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+	f.legacyAuthorizations[id] = authorization
+	writeFakeJSON(w, http.StatusCreated, authorization)
+}
+
+func (f *fakeInfluxDB) getLegacyAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	authorization, err := f.findLegacyAuthorization(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, authorization)
+}
+
+func (f *fakeInfluxDB) patchLegacyAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	authorization, err := f.findLegacyAuthorization(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	var request AuthorizationUpdateRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.Status != nil {
+		if *request.Status != AuthorizationUpdateRequestStatusActive && *request.Status != AuthorizationUpdateRequestStatusInactive {
+			newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "status must be active or inactive").write(w)
+			return
+		}
+		status := AuthorizationStatus(*request.Status)
+		authorization.Status = &status
+	}
+	if request.Description != nil {
+		authorization.Description = request.Description
+	}
+	now := time.Now().UTC()
+	authorization.UpdatedAt = &now
+	writeFakeJSON(w, http.StatusOK, authorization)
+}
+
+func (f *fakeInfluxDB) deleteLegacyAuthorization(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	if _, err := f.findLegacyAuthorization(id); err != nil {
+		err.write(w)
+		return
+	}
+	delete(f.legacyAuthorizations, id)
+	delete(f.legacyPasswords, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) postLegacyPassword(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	if _, err := f.findLegacyAuthorization(id); err != nil {
+		err.write(w)
+		return
+	}
+	var request PostLegacyAuthorizationsIDPasswordJSONBody
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if len(request.Password) < 8 {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "passwords must be at least 8 characters long").write(w)
+		return
+	}
+	f.legacyPasswords[id] = request.Password
+	f.legacyPasswordPosts++
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dbrpOrgID returns the organization of a dbrp call, which is required.
+func (f *fakeInfluxDB) dbrpOrgID(r *http.Request, orgID, org *string) (string, *fakeError) {
+	query := r.URL.Query()
+	if orgID == nil && query.Has("orgID") {
+		value := query.Get("orgID")
+		orgID = &value
+	}
+	if org == nil && query.Has("org") {
+		value := query.Get("org")
+		org = &value
+	}
+	switch {
+	case orgID != nil && *orgID != "":
+		if _, err := f.findOrg(*orgID); err != nil {
+			return "", err
+		}
+		return *orgID, nil
+	case org != nil && *org != "":
+		found := f.findOrgByName(*org)
+		if found == nil {
+			return "", newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "organization name %q not found", *org)
+		}
+		return *found.Id, nil
+	}
+	return "", newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "either 'org' or 'orgID' must be provided")
+}
+
+// listDBRPs returns the mappings of an organization. Buckets without a mapping
+// of their name get a virtual one, which is the default of its database when
+// there is no other.
+func (f *fakeInfluxDB) listDBRPs(orgID string) []domain.DBRP {
+	var dbrps []domain.DBRP
+	databases := map[string]bool{}
+	defaults := map[string]bool{}
+	for _, id := range sortedFakeIDs(f.dbrps) {
+		dbrp := f.dbrps[id]
+		if dbrp.OrgID != orgID {
+			continue
+		}
+		dbrps = append(dbrps, *dbrp)
+		databases[dbrp.Database+"/"+dbrp.RetentionPolicy] = true
+		defaults[dbrp.Database] = defaults[dbrp.Database] || dbrp.Default
+	}
+	for _, id := range sortedFakeIDs(f.buckets) {
+		bucket := f.buckets[id]
+		if *bucket.OrgID != orgID || *bucket.Type == domain.BucketTypeSystem {
+			continue
+		}
+		database, rp, found := strings.Cut(bucket.Name, "/")
+		if !found {
+			rp = "autogen"
+		}
+		if databases[database+"/"+rp] {
+			continue
+		}
+		virtual := true
+		dbrps = append(dbrps, domain.DBRP{
+			Id:              id,
+			OrgID:           orgID,
+			BucketID:        id,
+			Database:        database,
+			RetentionPolicy: rp,
+			Default:         !defaults[database],
+			Virtual:         &virtual,
+		})
+	}
+	return dbrps
+}
+
+func (f *fakeInfluxDB) findDBRP(orgID, id string) (*domain.DBRP, *fakeError) {
+	if dbrp, ok := f.dbrps[id]; ok && dbrp.OrgID == orgID {
+		return dbrp, nil
+	}
+	for _, dbrp := range f.listDBRPs(orgID) {
+		if dbrp.Id == id {
+			return &dbrp, nil
+		}
+	}
+	return nil, newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "unable to find DBRP")
+}
+
+// setDefaultDBRP makes a mapping the default of its database instead of the
+// previous default.
+func (f *fakeInfluxDB) setDefaultDBRP(dbrp *domain.DBRP) {
+	for _, other := range f.dbrps {
+		if other.OrgID == dbrp.OrgID && other.Database == dbrp.Database {
+			other.Default = false
+		}
+	}
+	dbrp.Default = true
+}
+
+func (f *fakeInfluxDB) getDBRPs(w http.ResponseWriter, r *http.Request, userID string) {
+	orgID, err := f.dbrpOrgID(r, nil, nil)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	query := r.URL.Query()
+	dbrps := []domain.DBRP{}
+	for _, dbrp := range f.listDBRPs(orgID) {
+		if (query.Has("id") && dbrp.Id != query.Get("id")) ||
+			(query.Has("bucketID") && dbrp.BucketID != query.Get("bucketID")) ||
+			(query.Has("db") && dbrp.Database != query.Get("db")) ||
+			(query.Has("rp") && dbrp.RetentionPolicy != query.Get("rp")) ||
+			(query.Has("default") && strconv.FormatBool(dbrp.Default) != query.Get("default")) {
+			continue
+		}
+		dbrps = append(dbrps, dbrp)
+	}
+	writeFakeJSON(w, http.StatusOK, domain.DBRPs{Content: &dbrps})
+}
+
+func (f *fakeInfluxDB) postDBRP(w http.ResponseWriter, r *http.Request, userID string) {
+	var request domain.DBRPCreate
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	orgID, err := f.dbrpOrgID(r, request.OrgID, request.Org)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	if request.Database == "" || request.RetentionPolicy == "" {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "database and retention policy are required").write(w)
+		return
+	}
+	bucket, err := f.findBucket(request.BucketID)
+	if err != nil || *bucket.OrgID != orgID {
+		newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "bucket not found").write(w)
+		return
+	}
+	isDefault := true
+	for _, other := range f.dbrps {
+		if other.OrgID != orgID || other.Database != request.Database {
+			continue
+		}
+		if other.RetentionPolicy == request.RetentionPolicy {
+			newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "another DBRP mapping with same orgID, db, and rp exists").write(w)
+			return
+		}
+		// The first mapping of a database is its default
+		isDefault = false
+	}
+	if request.Default != nil {
+		isDefault = *request.Default
+	}
+
+	id := f.newID()
+	virtual := false
+	dbrp := &domain.DBRP{
+		Id:              id,
+		OrgID:           orgID,
+		BucketID:        request.BucketID,
+		Database:        request.Database,
+		RetentionPolicy: request.RetentionPolicy,
+		Virtual:         &virtual,
+	}
+	f.dbrps[id] = dbrp
+	if isDefault {
+		f.setDefaultDBRP(dbrp)
+	}
+	writeFakeJSON(w, http.StatusCreated, dbrp)
+}
+
+func (f *fakeInfluxDB) getDBRP(w http.ResponseWriter, r *http.Request, userID string) {
+	orgID, err := f.dbrpOrgID(r, nil, nil)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	dbrp, err := f.findDBRP(orgID, r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, domain.DBRPGet{Content: dbrp})
+}
+
+func (f *fakeInfluxDB) patchDBRP(w http.ResponseWriter, r *http.Request, userID string) {
+	orgID, err := f.dbrpOrgID(r, nil, nil)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	dbrp, err := f.findDBRP(orgID, r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	if dbrp.Virtual != nil && *dbrp.Virtual {
+		newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "virtual DBRP mappings cannot be updated").write(w)
+		return
+	}
+	var request domain.DBRPUpdate
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	if request.RetentionPolicy != nil && *request.RetentionPolicy != dbrp.RetentionPolicy {
+		if *request.RetentionPolicy == "" {
+			newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "database and retention policy are required").write(w)
+			return
+		}
+		for _, other := range f.dbrps {
+			if other.OrgID == orgID && other.Database == dbrp.Database && other.RetentionPolicy == *request.RetentionPolicy {
+				newFakeError(http.StatusUnprocessableEntity, domain.ErrorCodeConflict, "another DBRP mapping with same orgID, db, and rp exists").write(w)
+				return
+			}
+		}
+		dbrp.RetentionPolicy = *request.RetentionPolicy
+	}
+	if request.Default != nil {
+		if *request.Default {
+			f.setDefaultDBRP(dbrp)
+		} else {
+			dbrp.Default = false
+		}
+	}
+	writeFakeJSON(w, http.StatusOK, domain.DBRPGet{Content: dbrp})
+}
+
+func (f *fakeInfluxDB) deleteDBRP(w http.ResponseWriter, r *http.Request, userID string) {
+	orgID, err := f.dbrpOrgID(r, nil, nil)
+	if err != nil {
+		err.write(w)
+		return
+	}
+	id := r.PathValue("id")
+	if dbrp, ok := f.dbrps[id]; !ok || dbrp.OrgID != orgID {
+		newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "unable to find DBRP").write(w)
+		return
+	}
+	delete(f.dbrps, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) findScraper(id string) (*domain.ScraperTargetResponse, *fakeError) {
+	scraper, ok := f.scrapers[id]
+	if !ok {
+		return nil, newFakeError(http.StatusNotFound, domain.ErrorCodeNotFound, "scraper target is not found")
+	}
+	return scraper, nil
+}
+
+// updateScraper applies a request to a scraper and checks the result.
+func (f *fakeInfluxDB) updateScraper(scraper *domain.ScraperTargetResponse, request domain.ScraperTargetRequest) *fakeError {
+	if request.Name != nil {
+		scraper.Name = request.Name
+	}
+	if request.Url != nil {
+		scraper.Url = request.Url
+	}
+	if request.Type != nil {
+		scraper.Type = request.Type
+	}
+	if request.AllowInsecure != nil {
+		scraper.AllowInsecure = request.AllowInsecure
+	}
+	if request.OrgID != nil {
+		scraper.OrgID = request.OrgID
+	}
+	if request.BucketID != nil {
+		scraper.BucketID = request.BucketID
+	}
+	if stringValue(scraper.Url) == "" {
+		return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "provided url is invalid")
+	}
+	if scraper.Type == nil || *scraper.Type != domain.ScraperTargetRequestTypePrometheus {
+		return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "unknown scraper type")
+	}
+	if stringValue(scraper.OrgID) == "" {
+		return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "org id is invalid")
+	}
+	org, err := f.findOrg(*scraper.OrgID)
+	if err != nil {
+		return err
+	}
+	if stringValue(scraper.BucketID) == "" {
+		return newFakeError(http.StatusBadRequest, domain.ErrorCodeInvalid, "bucket id is invalid")
+	}
+	bucket, err := f.findBucket(*scraper.BucketID)
+	if err != nil {
+		return err
+	}
+	scraper.Org = &org.Name
+	scraper.Bucket = &bucket.Name
+	return nil
+}
+
+func (f *fakeInfluxDB) getScrapers(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+	scrapers := []domain.ScraperTargetResponse{}
+	for _, id := range sortedFakeIDs(f.scrapers) {
+		scraper := f.scrapers[id]
+		if (query.Has("orgID") && *scraper.OrgID != query.Get("orgID")) ||
+			(query.Has("org") && *scraper.Org != query.Get("org")) ||
+			(query.Has("name") && *scraper.Name != query.Get("name")) ||
+			(query.Has("id") && !strings.Contains(","+strings.Join(query["id"], ",")+",", ","+id+",")) {
+			continue
+		}
+		scrapers = append(scrapers, *scraper)
+	}
+	writeFakeJSON(w, http.StatusOK, domain.ScraperTargetResponses{Configurations: &scrapers})
+}
+
+func (f *fakeInfluxDB) postScraper(w http.ResponseWriter, r *http.Request, userID string) {
+	var request domain.ScraperTargetRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	id := f.newID()
+	scraper := &domain.ScraperTargetResponse{Id: &id}
+	if err := f.updateScraper(scraper, request); err != nil {
+		err.write(w)
+		return
+	}
+	f.scrapers[id] = scraper
+	writeFakeJSON(w, http.StatusCreated, scraper)
+}
+
+func (f *fakeInfluxDB) getScraper(w http.ResponseWriter, r *http.Request, userID string) {
+	scraper, err := f.findScraper(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, scraper)
+}
+
+func (f *fakeInfluxDB) patchScraper(w http.ResponseWriter, r *http.Request, userID string) {
+	scraper, err := f.findScraper(r.PathValue("id"))
+	if err != nil {
+		err.write(w)
+		return
+	}
+	var request domain.ScraperTargetRequest
+	if !decodeFakeBody(w, r, &request) {
+		return
+	}
+	// Nothing changes when the request is refused
+	updated := *scraper
+	if err := f.updateScraper(&updated, request); err != nil {
+		err.write(w)
+		return
+	}
+	*scraper = updated
+	writeFakeJSON(w, http.StatusOK, scraper)
+}
+
+func (f *fakeInfluxDB) deleteScraper(w http.ResponseWriter, r *http.Request, userID string) {
+	id := r.PathValue("id")
+	if _, err := f.findScraper(id); err != nil {
+		err.write(w)
+		return
+	}
+	delete(f.scrapers, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestFakeInfluxDBEmptyIDs(t *testing.T) {
+	f := newFakeInfluxDB()
+	defer f.Close()
+	onboarding, err := f.setup()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	influx := influxdb2.NewClient(f.URL, *onboarding.Auth.Token)
+	defer influx.Close()
+
+	empty := ""
+	for _, resource := range []domain.Resource{
+		{Type: domain.ResourceTypeBuckets, Id: &empty, OrgID: onboarding.Org.Id},
+		{Type: domain.ResourceTypeBuckets, OrgID: &empty},
+	} {
+		permissions := []domain.Permission{{Action: domain.PermissionActionRead, Resource: resource}}
+		_, err := influx.AuthorizationsAPI().CreateAuthorization(context.Background(), &domain.Authorization{
+			OrgID:       onboarding.Org.Id,
+			Permissions: &permissions,
+		})
+		if err == nil || !strings.Contains(err.Error(), "id must have a length of 16 bytes") {
+			t.Fatalf("expected empty ids to be refused, got: %v", err)
+		}
+	}
+}
+
+// TestFakeInfluxDBResources runs resources against a fresh fake server per
+// case, for what the acceptance tests can't provoke on a shared server: the
+// errors the server answers with and the rotation of tokens.
+func TestFakeInfluxDBResources(t *testing.T) {
+	cases := []struct {
+		name  string
+		steps func(f *fakeInfluxDB, config func(string) string) []resource.TestStep
+	}{
+		{
+			name: "bucket name conflict",
+			steps: func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+				return []resource.TestStep{{
+					Config: config(`
+resource "influxdb-v2_bucket" "first" {
+	name = "acctest"
+	deletion_protection = false
+	retention_rules {
+		every_seconds = 3600
+	}
+}
+resource "influxdb-v2_bucket" "second" {
+	name = influxdb-v2_bucket.first.name
+	deletion_protection = false
+	retention_rules {
+		every_seconds = 3600
+	}
+}
+`),
+					ExpectError: regexp.MustCompile("conflict: bucket with name acctest already exists"),
+				}}
+			},
+		},
+		{
+			name: "organization name conflict",
+			steps: func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+				return []resource.TestStep{{
+					Config: config(`
+resource "influxdb-v2_organization" "acctest" {
+	name = "testorg"
+}
+`),
+					ExpectError: regexp.MustCompile("conflict: organization with name testorg already exists"),
+				}}
+			},
+		},
+		{
+			name: "dbrp mapping conflict",
+			steps: func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+				return []resource.TestStep{{
+					Config: config(`
+resource "influxdb-v2_dbrp_mapping" "first" {
+	bucket_id = data.influxdb-v2_bucket.testbucket.id
+	database = "legacy_database"
+	retention_policy = "legacy_rp"
+}
+resource "influxdb-v2_dbrp_mapping" "second" {
+	bucket_id = influxdb-v2_dbrp_mapping.first.bucket_id
+	database = "legacy_database"
+	retention_policy = "legacy_rp"
+}
+data "influxdb-v2_bucket" "testbucket" {
+	name = "testbucket"
+}
+`),
+					ExpectError: regexp.MustCompile("conflict: another DBRP mapping with same orgID, db, and rp exists"),
+				}}
+			},
+		},
+		{
+			name: "scraper of a missing bucket",
+			steps: func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+				return []resource.TestStep{{
+					Config: config(`
+resource "influxdb-v2_scraper" "acctest" {
+	name = "acctest"
+	bucket_id = "0000000000000001"
+	allow_insecure = false
+	url = "http://localhost:8086/metrics"
+}
+`),
+					ExpectError: regexp.MustCompile("not found: bucket not found"),
+				}}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testFakeInfluxDB(t, c.steps)
+		})
+	}
+}
+
+// testFakeInfluxDB runs steps against a fake server of their own, for tests
+// that change or inspect the server. config adds the provider block of the
+// server to the resources of a step.
+func testFakeInfluxDB(t *testing.T, steps func(f *fakeInfluxDB, config func(string) string) []resource.TestStep) {
+	f := newFakeInfluxDB()
+	defer f.Close()
+	onboarding, err := f.setup()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	config := func(resources string) string {
+		return fmt.Sprintf(`
+provider "influxdb-v2" {
+	url = %q
+	token = %q
+	org_id = %q
+}
+`, f.URL, *onboarding.Auth.Token, *onboarding.Org.Id) + resources
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps:             steps(f, config),
+	})
+}
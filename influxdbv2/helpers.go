@@ -1,9 +1,15 @@
 package influxdbv2
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	nethttp "net/http"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/influxdata/influxdb-client-go/v2/api/http"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
 func createUpdatedSchema(itemType string) map[string]*schema.Schema {
@@ -31,6 +37,84 @@ func createUpdatedSchema(itemType string) map[string]*schema.Schema {
 	}
 }
 
+// endpointSchema lets a resource be managed on another server than the
+// provider's, so that one provider can manage a fleet of servers. It would be
+// named connection, but Terraform reserves that name for provisioners.
+func endpointSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Description: "Server of the resource, when it is not the provider's.",
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"url": {
+					Type:     schema.TypeString,
+					Required: true,
+					ForceNew: true,
+				},
+				"token": {
+					Type:        schema.TypeString,
+					Description: "Required unless url is the provider's, whose token or sign-in it defaults to.",
+					Optional:    true,
+					Sensitive:   true,
+				},
+				"tls": {
+					Type:        schema.TypeList,
+					Description: "Defaults to the TLS settings of the provider.",
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"skip_ssl_verify": {
+								Type:     schema.TypeBool,
+								Optional: true,
+							},
+							"ca_cert_pem": {
+								Type:     schema.TypeString,
+								Optional: true,
+							},
+							"client_cert_pem": {
+								Type:         schema.TypeString,
+								Optional:     true,
+								RequiredWith: []string{"endpoint.0.tls.0.client_key_pem"},
+							},
+							"client_key_pem": {
+								Type:         schema.TypeString,
+								Optional:     true,
+								Sensitive:    true,
+								RequiredWith: []string{"endpoint.0.tls.0.client_cert_pem"},
+							},
+							"server_name": {
+								Type:     schema.TypeString,
+								Optional: true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// stringValue returns the value of an optional string of the API, or an empty
+// string.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// optionalString is the reverse of stringValue: an empty string is left out
+// of the API call rather than sent as an empty value.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func mergeSchemas(schemas ...map[string]*schema.Schema) map[string]*schema.Schema {
 	res := map[string]*schema.Schema{}
 	for _, s := range schemas {
@@ -40,3 +124,80 @@ func mergeSchemas(schemas ...map[string]*schema.Schema) map[string]*schema.Schem
 	}
 	return res
 }
+
+// isNotFoundError reports whether err is a 404 response of the InfluxDB API.
+// The generated API client only keeps the code and message of a response, so
+// its errors are recognized by the "not found" code instead.
+func isNotFoundError(err error) bool {
+	var httpErr *http.Error
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == nethttp.StatusNotFound
+	}
+	return strings.HasPrefix(err.Error(), string(domain.ErrorCodeNotFound)+":")
+}
+
+// getOrgID returns the organization a resource is created in: its own org_id,
+// the ID of its org name, or else the provider's default organization.
+func getOrgID(d *schema.ResourceData, m interface{}) (string, error) {
+	if id := d.Get("org_id").(string); id != "" {
+		return id, nil
+	}
+	if org := d.Get("org").(string); org != "" {
+		return findOrgIDByName(context.Background(), d, m, org)
+	}
+	id, err := m.(*meta).getDefaultOrgID(d)
+	if err != nil {
+		return "", err
+	}
+	if id != "" {
+		return id, nil
+	}
+	return "", fmt.Errorf("org_id or org must be set on the resource or the provider")
+}
+
+// orgCustomizeDiff plans the org_id of a resource that doesn't configure it, so
+// that the organization resolved from its org name or the provider's default
+// shows up in the plan. Existing resources are never moved to a new default.
+// Organizations named on new resources are resolved on create, so that they
+// can be planned before the server exists.
+func orgCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.GetAttr("org_id").IsNull() {
+		return nil
+	}
+	if !d.NewValueKnown("org") {
+		return d.SetNewComputed("org_id")
+	}
+	if org := d.Get("org").(string); org != "" {
+		if d.Id() == "" {
+			return d.SetNewComputed("org_id")
+		}
+		id, err := findOrgIDByName(ctx, d, m, org)
+		if err != nil {
+			return err
+		}
+		return d.SetNew("org_id", id)
+	}
+	if d.Id() != "" {
+		return nil
+	}
+	if id := m.(*meta).defaultOrgID; id != "" {
+		return d.SetNew("org_id", id)
+	}
+	if m.(*meta).defaultOrg != "" {
+		return d.SetNewComputed("org_id")
+	}
+	return fmt.Errorf("org_id or org must be set on the resource or the provider")
+}
+
+func findOrgIDByName(ctx context.Context, d resourceGetter, m interface{}, name string) (string, error) {
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return "", err
+	}
+	org, err := influx.OrganizationsAPI().FindOrganizationByName(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("error finding organization %s: %v", name, err)
+	}
+	return *org.Id, nil
+}
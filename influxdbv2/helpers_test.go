@@ -0,0 +1,24 @@
+package influxdbv2
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/http"
+)
+
+func TestIsNotFoundError(t *testing.T) {
+	cases := map[error]bool{
+		&http.Error{StatusCode: 404}:                                                        true,
+		fmt.Errorf("error reading: %w", &http.Error{StatusCode: 404}):                       true,
+		&http.Error{StatusCode: 400, Code: "invalid", Message: "not found: in the message"}: false,
+		errors.New("not found: authorization not found"):                                    true,
+		errors.New("invalid: id must have a length of 16 bytes"):                            false,
+	}
+	for err, want := range cases {
+		if got := isNotFoundError(err); got != want {
+			t.Errorf("expected isNotFoundError(%q) to be %t", err, want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package influxdbv2
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+// meta is what resources and data sources receive from the provider. Clients
+// only reach the server on the first API call, so that a plan can be made
+// before the server exists, for example when it is created in the same
+// configuration.
+type meta struct {
+	// Connection configured on the provider, and the URL of its server
+	provider *connection
+	url      string
+	// Organization of resources that don't set their own, by ID or by name
+	defaultOrgID string
+	defaultOrg   string
+	// Refuse authorizations that write privileged resource types
+	denyPrivilegedTokens bool
+	// Used to connect to the endpoints of resources that override the provider
+	options connectionOptions
+
+	// Connections of resources that override the provider, by endpoint
+	connectionsLock sync.Mutex
+	connections     map[string]*connection
+}
+
+// resourceGetter is implemented by both schema.ResourceData and
+// schema.ResourceDiff.
+type resourceGetter interface {
+	Get(key string) interface{}
+}
+
+// connectionOptions are the settings of the provider that every connection
+// shares.
+type connectionOptions struct {
+	// Used by resources that don't set their own
+	token     string
+	tlsConfig *tls.Config
+
+	transport    transportOptions
+	proxy        func(*http.Request) (*url.URL, error)
+	check        string
+	readyTimeout time.Duration
+}
+
+// connection holds the clients of one server.
+type connection struct {
+	influxsdk                  influxdb2.Client
+	legacyAuthorizationsClient *ClientWithResponses
+
+	// Checks that the server is up and signs in, once before the first API call
+	connect    func(ctx context.Context) error
+	once       sync.Once
+	connectErr error
+	// What the server supports, found once connected
+	server serverInfo
+
+	// ID of the organization named on the provider, found when a resource
+	// first needs it
+	defaultOrgLock sync.Mutex
+	defaultOrgID   string
+}
+
+// newConnection creates the clients of a server, which share one http client.
+// Nothing is requested until the connection is first used.
+func newConnection(serverURL, token string, tlsConfig *tls.Config, options connectionOptions) (*connection, error) {
+	base := newBaseTransport()
+	base.TLSClientConfig = tlsConfig
+	if options.proxy != nil {
+		base.Proxy = options.proxy
+	}
+	httpClient := newHTTPClient(base, options.transport)
+	opts := influxdb2.DefaultOptions().SetHTTPClient(httpClient)
+	influx := influxdb2.NewClientWithOptions(serverURL, token, opts)
+
+	addToken := func(ctx context.Context, req *http.Request) error {
+		if token != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Token %s", token))
+		}
+		return nil
+	}
+	legacy, err := NewClientWithResponses(fmt.Sprint(serverURL, "/private"), WithRequestEditorFn(addToken), WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("error creating legacy client: %s", err)
+	}
+	return &connection{
+		influxsdk:                  influx,
+		legacyAuthorizationsClient: legacy,
+		connect: func(ctx context.Context) error {
+			return waitForServer(ctx, influx, options.check, options.readyTimeout)
+		},
+	}, nil
+}
+
+func (c *connection) ready() error {
+	c.once.Do(func() {
+		ctx := context.Background()
+		if c.connect != nil {
+			c.connectErr = c.connect(ctx)
+			if c.connectErr != nil {
+				return
+			}
+		}
+		c.server = detectServer(ctx, c.influxsdk)
+	})
+	return c.connectErr
+}
+
+// connection returns the connection of a resource: the one set by its
+// endpoint block, or else the provider's. A nil d stands for the provider's.
+func (m *meta) connection(d resourceGetter) (*connection, error) {
+	if d == nil {
+		return m.provider, nil
+	}
+	blocks, ok := d.Get("endpoint").([]interface{})
+	if !ok || len(blocks) == 0 || blocks[0] == nil {
+		return m.provider, nil
+	}
+	block := blocks[0].(map[string]interface{})
+	endpoint := block["url"].(string)
+	token := block["token"].(string)
+	tlsBlocks := block["tls"].([]interface{})
+	customTLS := len(tlsBlocks) > 0 && tlsBlocks[0] != nil
+	if token == "" {
+		// The token of the provider is only ever sent to the provider's server
+		if strings.TrimSuffix(endpoint, "/") != strings.TrimSuffix(m.url, "/") {
+			return nil, fmt.Errorf("endpoint.token must be set for %s, which is not the server of the provider", endpoint)
+		}
+		// The provider may have signed in instead of having a token, so its
+		// session is used unless the endpoint has TLS settings of its own
+		if !customTLS {
+			return m.provider, nil
+		}
+		if m.options.token == "" {
+			return nil, fmt.Errorf("endpoint.token must be set for %s with tls settings, as the provider has no token", endpoint)
+		}
+		token = m.options.token
+	}
+
+	tlsConfig := m.options.tlsConfig
+	var tlsKey string
+	if customTLS {
+		settings := tlsBlocks[0].(map[string]interface{})
+		var err error
+		tlsConfig, err = newTLSConfig(
+			settings["skip_ssl_verify"].(bool),
+			settings["server_name"].(string),
+			[]byte(settings["ca_cert_pem"].(string)),
+			settings["client_cert_pem"].(string),
+			settings["client_key_pem"].(string),
+		)
+		if err != nil {
+			return nil, err
+		}
+		tlsKey = fmt.Sprint(settings["skip_ssl_verify"], settings["server_name"], settings["ca_cert_pem"], settings["client_cert_pem"], settings["client_key_pem"])
+	}
+
+	key := strings.Join([]string{endpoint, token, tlsKey}, "\x00")
+	m.connectionsLock.Lock()
+	defer m.connectionsLock.Unlock()
+	if c, ok := m.connections[key]; ok {
+		return c, nil
+	}
+	c, err := newConnection(endpoint, token, tlsConfig, m.options)
+	if err != nil {
+		return nil, err
+	}
+	if m.connections == nil {
+		m.connections = map[string]*connection{}
+	}
+	m.connections[key] = c
+	return c, nil
+}
+
+func (m *meta) readyConnection(d resourceGetter) (*connection, error) {
+	c, err := m.connection(d)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ready(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// client returns the SDK client of a resource once its server is ready.
+func (m *meta) client(d resourceGetter) (influxdb2.Client, error) {
+	c, err := m.readyConnection(d)
+	if err != nil {
+		return nil, err
+	}
+	return c.influxsdk, nil
+}
+
+// legacyClient returns the client of the legacy authorizations API of a
+// resource once its server is ready.
+func (m *meta) legacyClient(d resourceGetter) (*ClientWithResponses, error) {
+	c, err := m.readyConnection(d)
+	if err != nil {
+		return nil, err
+	}
+	return c.legacyAuthorizationsClient, nil
+}
+
+// getServer describes the server of a resource once it is ready.
+func (m *meta) getServer(d resourceGetter) (serverInfo, error) {
+	c, err := m.readyConnection(d)
+	if err != nil {
+		return serverInfo{}, err
+	}
+	return c.server, nil
+}
+
+// How long planning waits for the server of a resource to describe itself
+const probeServerTimeout = 5 * time.Second
+
+// probeServer describes the server of a resource at plan time. Unlike
+// getServer it makes a single request to /health, without waiting for the
+// server to be ready or signing in, so that planning isn't held up by a server
+// that doesn't exist yet and doesn't fail the apply that creates it.
+func (m *meta) probeServer(ctx context.Context, d resourceGetter) (serverInfo, error) {
+	c, err := m.connection(d)
+	if err != nil {
+		return serverInfo{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, probeServerTimeout)
+	defer cancel()
+	return detectServer(ctx, c.influxsdk), nil
+}
+
+// getDefaultOrgID returns the ID of the provider's default organization on the
+// server of a resource, or an empty string when there is none. An organization
+// named on the provider is looked up on each server the first time a resource
+// needs it. It may be created by the same apply, so a failed lookup is only an
+// error of that resource and is tried again by the next one.
+func (m *meta) getDefaultOrgID(d resourceGetter) (string, error) {
+	if m.defaultOrgID != "" || m.defaultOrg == "" {
+		return m.defaultOrgID, nil
+	}
+	c, err := m.readyConnection(d)
+	if err != nil {
+		return "", err
+	}
+	c.defaultOrgLock.Lock()
+	defer c.defaultOrgLock.Unlock()
+	if c.defaultOrgID == "" {
+		org, err := c.influxsdk.OrganizationsAPI().FindOrganizationByName(context.Background(), m.defaultOrg)
+		if err != nil {
+			return "", fmt.Errorf("error finding organization %s: %v", m.defaultOrg, err)
+		}
+		c.defaultOrgID = *org.Id
+	}
+	return c.defaultOrgID, nil
+}
+
+// waitForServer runs the health check until it passes or the timeout has
+// elapsed, so that a server that is still starting isn't rejected.
+func waitForServer(ctx context.Context, influx influxdb2.Client, check string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := checkHealth(ctx, influx, check)
+		if err == nil || !time.Now().Before(deadline) {
+			return err
+		}
+		wait := time.Until(deadline)
+		if wait > time.Second {
+			wait = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func checkHealth(ctx context.Context, influx influxdb2.Client, check string) error {
+	switch check {
+	case "none":
+		return nil
+	case "ping":
+		_, err := influx.Ping(ctx)
+		if err != nil {
+			return fmt.Errorf("error pinging server on /ping: %s", err)
+		}
+	default:
+		_, err := influx.Ready(ctx)
+		if err != nil {
+			return fmt.Errorf("error pinging server on /ready: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package influxdbv2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestMetaConnectionPerEndpoint(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":   "http://influxdb.example.com:8086",
+		"token": "provider",
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	provider := m.(*meta)
+
+	bucket := ResourceBucket().Schema
+	d = schema.TestResourceDataRaw(t, bucket, map[string]interface{}{"name": "b"})
+	c, err := provider.connection(d)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c != provider.provider {
+		t.Fatal("expected resources without an endpoint to use the provider's connection")
+	}
+
+	// The provider's token is not sent to other servers
+	edge := map[string]interface{}{
+		"name": "b",
+		"endpoint": []interface{}{
+			map[string]interface{}{"url": "http://edge-1.example.com:8086"},
+		},
+	}
+	if _, err := provider.connection(schema.TestResourceDataRaw(t, bucket, edge)); err == nil {
+		t.Fatal("expected an endpoint on another server to need its own token")
+	}
+	same := map[string]interface{}{
+		"name": "b",
+		"endpoint": []interface{}{
+			map[string]interface{}{"url": "http://influxdb.example.com:8086/"},
+		},
+	}
+	c, err = provider.connection(schema.TestResourceDataRaw(t, bucket, same))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c != provider.provider {
+		t.Fatal("expected an endpoint on the provider's server without a token to use the provider's connection")
+	}
+
+	edge["endpoint"] = []interface{}{
+		map[string]interface{}{"url": "http://edge-1.example.com:8086", "token": "edge"},
+	}
+	c, err = provider.connection(schema.TestResourceDataRaw(t, bucket, edge))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.influxsdk.ServerURL() != "http://edge-1.example.com:8086" {
+		t.Fatalf("expected the endpoint's url but got %s", c.influxsdk.ServerURL())
+	}
+	again, err := provider.connection(schema.TestResourceDataRaw(t, bucket, edge))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if again != c {
+		t.Fatal("expected the connection of an endpoint to be reused")
+	}
+	if len(provider.connections) != 1 {
+		t.Fatalf("expected 1 cached connection but got %d", len(provider.connections))
+	}
+}
+
+func TestMetaProbeServerDoesNotWait(t *testing.T) {
+	// Nothing listens on the port, as when the server is created by the apply
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":           "http://127.0.0.1:1",
+		"token":         "provider",
+		"ready_timeout": "1m",
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	start := time.Now()
+	server, err := m.(*meta).probeServer(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if server.cloud {
+		t.Fatal("expected a server that can't be reached to be assumed to be OSS")
+	}
+	if elapsed := time.Since(start); elapsed > probeServerTimeout {
+		t.Fatalf("expected the probe not to wait for the server to be ready, it took %s", elapsed)
+	}
+}
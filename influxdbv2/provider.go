@@ -3,25 +3,29 @@ package influxdbv2
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"os"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
-	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 )
 
-type meta struct {
-	influxsdk                  influxdb2.Client
-	legacyAuthorizationsClient *ClientWithResponses
-}
-
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		DataSourcesMap: map[string]*schema.Resource{
-			"influxdb-v2_ready":        DataReady(),
-			"influxdb-v2_organization": dataSourceOrganization(),
-			"influxdb-v2_bucket":       dataSourceBucket(),
+			"influxdb-v2_ready":         DataReady(),
+			"influxdb-v2_organization":  dataSourceOrganization(),
+			"influxdb-v2_bucket":        dataSourceBucket(),
+			"influxdb-v2_scraper":       dataSourceScraper(),
+			"influxdb-v2_scrapers":      dataSourceScrapers(),
+			"influxdb-v2_dbrp_mappings": dataSourceDBRPMappings(),
+			"influxdb-v2_permissions":   dataSourcePermissions(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"influxdb-v2_bucket":               ResourceBucket(),
@@ -30,6 +34,7 @@ func Provider() *schema.Provider {
 			"influxdb-v2_legacy_authorization": ResourceLegacyAuthorization(),
 			"influxdb-v2_dbrp_mapping":         ResourceDBRPMapping(),
 			"influxdb-v2_scraper":              ResourceScraper(),
+			"influxdb-v2_delete_data":          ResourceDeleteData(),
 		},
 		Schema: map[string]*schema.Schema{
 			"url": {
@@ -46,65 +51,253 @@ func Provider() *schema.Provider {
 				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_V2_TOKEN", ""),
 			},
+			"org_id": {
+				Type:        schema.TypeString,
+				Description: "ID of the default organization of resources that don't set org_id or org",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_V2_ORG_ID", ""),
+			},
+			"org": {
+				Type:        schema.TypeString,
+				Description: "name of the default organization of resources that don't set org_id or org, ignored when org_id is set",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_V2_ORG", ""),
+			},
+			"username": {
+				Type:         schema.TypeString,
+				Description:  "sign in with a username and password instead of a token",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_V2_USERNAME", ""),
+				RequiredWith: []string{"password"},
+			},
+			"password": {
+				Type:         schema.TypeString,
+				Description:  "password of the user to sign in with",
+				Optional:     true,
+				Sensitive:    true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_V2_PASSWORD", ""),
+				RequiredWith: []string{"username"},
+			},
 			"skip_ssl_verify": {
 				Type:        schema.TypeBool,
 				Description: "skip ssl verify on connection",
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_SKIP_SSL_VERIFY", "0"),
 			},
+			"ca_cert_pem": {
+				Type:          schema.TypeString,
+				Description:   "PEM encoded CA bundle to verify the server certificate with",
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_file"},
+			},
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				Description:   "path to a PEM encoded CA bundle to verify the server certificate with",
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("INFLUXDB_V2_CA_CERT_FILE", ""),
+				ConflictsWith: []string{"ca_cert_pem"},
+			},
+			"client_cert_pem": {
+				Type:         schema.TypeString,
+				Description:  "PEM encoded client certificate for mutual TLS",
+				Optional:     true,
+				RequiredWith: []string{"client_key_pem"},
+			},
+			"client_key_pem": {
+				Type:         schema.TypeString,
+				Description:  "PEM encoded private key of the client certificate",
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"client_cert_pem"},
+			},
+			"tls_server_name": {
+				Type:        schema.TypeString,
+				Description: "server name used to verify the server certificate, when it differs from the host in url",
+				Optional:    true,
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Description: "headers added to every request to the server, redacted from logs",
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Description: "URL of the proxy to send requests through instead of the one from HTTPS_PROXY",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_V2_PROXY_URL", ""),
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Description:  "number of times a request is retried when the server is rate limiting or unavailable (429, 502, 503 and 504). POST requests are only retried on 429, or 503 with Retry-After. A Retry-After longer than 30s is not waited for",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_V2_MAX_RETRIES", 3),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Description:  "maximum number of requests in flight at once, 0 means unlimited",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_V2_MAX_CONCURRENT_REQUESTS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"request_timeout_seconds": {
+				Type:         schema.TypeInt,
+				Description:  "timeout of each request to the server, 0 means no timeout",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_V2_REQUEST_TIMEOUT_SECONDS", 20),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
 			"health_check": {
 				Type:         schema.TypeString,
-				Description:  "use /ping instead of /ready to check connection to host",
+				Description:  "use /ping instead of /ready to check connection to host, or none to skip the check",
 				Optional:     true,
 				Default:      "ready",
-				ValidateFunc: validation.StringInSlice([]string{"ready", "ping"}, false),
+				ValidateFunc: validation.StringInSlice([]string{"ready", "ping", "none"}, false),
+			},
+			"ready_timeout": {
+				Type:         schema.TypeString,
+				Description:  "how long to wait for the server to pass the health check, such as 30s or 5m",
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_V2_READY_TIMEOUT", "0s"),
+				ValidateFunc: validateDuration,
+			},
+			"deny_privileged_tokens": {
+				Type:        schema.TypeBool,
+				Description: "refuse to plan authorizations that write authorizations, users or orgs instead of warning about them",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_V2_DENY_PRIVILEGED_TOKENS", false),
 			},
 		},
-		ConfigureFunc: providerConfigure,
+		ConfigureContextFunc: providerConfigure,
 	}
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	url := d.Get("url").(string)
 	token := d.Get("token").(string)
-	sslv := d.Get("skip_ssl_verify").(bool)
+	username := d.Get("username").(string)
+	password := d.Get("password").(string)
 	check := d.Get("health_check").(string)
-	tls := &tls.Config{
-		InsecureSkipVerify: sslv,
+	readyTimeout, err := time.ParseDuration(d.Get("ready_timeout").(string))
+	if err != nil {
+		return nil, diag.Errorf("error parsing ready_timeout: %s", err)
 	}
-	opts := influxdb2.DefaultOptions().SetTLSConfig(tls)
-	influx := influxdb2.NewClientWithOptions(url, token, opts)
-
-	if check == "ping" {
-		_, err := influx.Ping(context.Background())
+	tlsConfig, err := getTLSConfig(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	var proxy func(*http.Request) (*neturl.URL, error)
+	if proxyURL := d.Get("proxy_url").(string); proxyURL != "" {
+		proxy, err = newProxyFunc(proxyURL)
 		if err != nil {
-			return nil, fmt.Errorf("error pinging server on /ping: %s", err)
+			return nil, diag.Errorf("error parsing proxy_url: %s", err)
 		}
-	} else {
-		_, err := influx.Ready(context.Background())
+	}
+	headers := map[string]string{}
+	for key, value := range d.Get("headers").(map[string]interface{}) {
+		headers[key] = value.(string)
+	}
+	var logContext context.Context
+	if logging.IsDebugOrHigher() {
+		logContext = ctx
+	}
+	m := &meta{
+		url:                  url,
+		defaultOrgID:         d.Get("org_id").(string),
+		defaultOrg:           d.Get("org").(string),
+		denyPrivilegedTokens: d.Get("deny_privileged_tokens").(bool),
+		options: connectionOptions{
+			token:     token,
+			tlsConfig: tlsConfig,
+			transport: transportOptions{
+				logContext:            logContext,
+				headers:               headers,
+				maxRetries:            d.Get("max_retries").(int),
+				maxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+				requestTimeout:        time.Duration(d.Get("request_timeout_seconds").(int)) * time.Second,
+				minBackoff:            time.Second,
+				maxBackoff:            30 * time.Second,
+			},
+			proxy:        proxy,
+			check:        check,
+			readyTimeout: readyTimeout,
+		},
+	}
+	// A session replaces the token, the cookie it sets is kept on the http
+	// client which is shared with the legacy client
+	providerToken := token
+	if username != "" {
+		providerToken = ""
+	}
+	m.provider, err = newConnection(url, providerToken, tlsConfig, m.options)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	if username != "" {
+		influx := m.provider.influxsdk
+		healthCheck := m.provider.connect
+		m.provider.connect = func(ctx context.Context) error {
+			err := healthCheck(ctx)
+			if err != nil {
+				return err
+			}
+			err = influx.UsersAPI().SignIn(ctx, username, password)
+			if err != nil {
+				return fmt.Errorf("error signing in as %s: %s", username, err)
+			}
+			trackSession(influx)
+			return nil
+		}
+	}
+	return m, nil
+}
+
+func validateDuration(v interface{}, k string) (ws []string, es []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		es = append(es, fmt.Errorf("expected %s to be a duration such as 30s or 5m, got %q", k, v))
+	}
+	return
+}
+
+func getTLSConfig(d *schema.ResourceData) (*tls.Config, error) {
+	caPEM := []byte(d.Get("ca_cert_pem").(string))
+	if file := d.Get("ca_cert_file").(string); file != "" {
+		var err error
+		caPEM, err = os.ReadFile(file)
 		if err != nil {
-			return nil, fmt.Errorf("error pinging server on /ready: %s", err)
+			return nil, fmt.Errorf("error reading ca_cert_file: %s", err)
 		}
 	}
+	return newTLSConfig(
+		d.Get("skip_ssl_verify").(bool),
+		d.Get("tls_server_name").(string),
+		caPEM,
+		d.Get("client_cert_pem").(string),
+		d.Get("client_key_pem").(string),
+	)
+}
 
-	addToken := func(ctx context.Context, req *http.Request) error {
-		req.Header.Add("Authorization", fmt.Sprintf("Token %s", token))
-		return nil
+func newTLSConfig(skipVerify bool, serverName string, caPEM []byte, certPEM, keyPEM string) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: skipVerify,
+		ServerName:         serverName,
 	}
-	skipSSLVerify := func(client *Client) error {
-		httpClient := &http.Client{}
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: tls,
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("error reading CA bundle: no PEM encoded certificates found")
 		}
-		client.Client = httpClient
-		return nil
+		config.RootCAs = pool
 	}
-	legacy, err := NewClientWithResponses(fmt.Sprint(url, "/private"), WithRequestEditorFn(addToken), skipSSLVerify)
-	if err != nil {
-		return nil, fmt.Errorf("error creating legacy client: %s", err)
+	if certPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("error reading client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
 	}
-	return meta{
-		influxsdk:                  influx,
-		legacyAuthorizationsClient: legacy,
-	}, nil
+	return config, nil
 }
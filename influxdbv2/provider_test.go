@@ -1,7 +1,13 @@
 package influxdbv2
 
 import (
+	"context"
+	"encoding/pem"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -14,14 +20,189 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestProviderConfigureCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// Without the CA bundle the server certificate cannot be verified
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":          server.URL,
+		"health_check": "ping",
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if _, err := m.(*meta).client(nil); err == nil {
+		t.Fatal("expected the connection to fail without the CA bundle")
+	}
+
+	d = schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":             server.URL,
+		"health_check":    "ping",
+		"ca_cert_pem":     string(caPEM),
+		"tls_server_name": "example.com",
+	})
+	m, diags = providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if _, err := m.(*meta).client(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestProviderConfigureLazily(t *testing.T) {
+	var ready int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// The server only becomes ready after a couple of health checks
+		if atomic.AddInt32(&ready, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	url := server.URL
+	server.Close()
+
+	// Nothing is requested until the first API call, so the server doesn't
+	// need to exist when the provider is configured
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":          url,
+		"health_check": "ping",
+		"max_retries":  0,
+	})
+	if _, diags := providerConfigure(context.Background(), d); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+
+	server = httptest.NewServer(server.Config.Handler)
+	defer server.Close()
+	d = schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":           server.URL,
+		"health_check":  "ping",
+		"max_retries":   0,
+		"ready_timeout": "10s",
+	})
+	m, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if _, err := m.(*meta).client(nil); err != nil {
+		t.Fatalf("expected the client to wait for the server but got: %s", err)
+	}
+	if ready != 3 {
+		t.Fatalf("expected 3 health checks but got %d", ready)
+	}
+}
+
+func TestAccProviderSignIn(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// Credentials are the ones used to onboard the instance in scripts/setup_influxdb.sh
+				Config: `
+provider "influxdb-v2" {
+	token    = ""
+	username = "admin"
+	password = "password"
+}
+
+data "influxdb-v2_organization" "acctest" {
+	name = "testorg"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.influxdb-v2_organization.acctest", "id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProviderSignInEndpoint(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccBucketDestroyed,
+		Steps: []resource.TestStep{
+			{
+				// An endpoint on the provider's server uses its session
+				Config: `
+provider "influxdb-v2" {
+	token    = ""
+	username = "admin"
+	password = "password"
+}
+
+resource "influxdb-v2_bucket" "acctest" {
+	name = "acctest"
+	org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+	deletion_protection = false
+	retention_rules {
+		every_seconds = 3600
+	}
+	endpoint {
+		url = "` + os.Getenv("INFLUXDB_V2_URL") + `"
+	}
+}
+`,
+				Check: resource.TestCheckResourceAttrSet("influxdb-v2_bucket.acctest", "id"),
+			},
+		},
+	})
+}
+
 func TestMain(m *testing.M) {
+	// Without a server to test against, acceptance tests run against an
+	// in-memory one onboarded as scripts/setup_influxdb.sh does
+	if os.Getenv("INFLUXDB_V2_URL") == "" {
+		testAccFakeServer = true
+		f := newFakeInfluxDB()
+		onboarding, err := f.setup()
+		if err != nil {
+			log.Fatalf("error setting up the fake server: %s", err)
+		}
+		os.Setenv("INFLUXDB_V2_URL", f.URL)
+		os.Setenv("INFLUXDB_V2_TOKEN", *onboarding.Auth.Token)
+		os.Setenv("INFLUXDB_V2_ORG_ID", *onboarding.Org.Id)
+		os.Setenv("INFLUXDB_V2_BUCKET_ID", *onboarding.Bucket.Id)
+	}
 	resource.TestMain(m)
 }
 
+// testAccFakeServer is set when the tests run against the in-memory server.
+var testAccFakeServer bool
+
+// testAccRun runs an acceptance test. Against the in-memory server it always
+// runs, while a real one is only changed when TF_ACC is set.
+func testAccRun(t *testing.T, c resource.TestCase) {
+	if testAccFakeServer {
+		resource.UnitTest(t, c)
+		return
+	}
+	resource.Test(t, c)
+}
+
 var testAccProviders = map[string]*schema.Provider{
 	"influxdb-v2": Provider(),
 }
 
+// testAccProviderFactories are for configurations with their own provider
+// block, to which Providers would add an empty one.
+var testAccProviderFactories = map[string]func() (*schema.Provider, error){
+	"influxdb-v2": func() (*schema.Provider, error) { return Provider(), nil },
+}
+
 func testAccPreCheck(t *testing.T) {
 	if v := os.Getenv("INFLUXDB_V2_TOKEN"); v == "" {
 		t.Fatal("INFLUXDB_V2_TOKEN must be set for acceptance tests")
@@ -3,8 +3,14 @@ package influxdbv2
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
@@ -14,11 +20,28 @@ func ResourceAuthorization() *schema.Resource {
 		Delete: resourceAuthorizationDelete,
 		Read:   resourceAuthorizationRead,
 		Update: resourceAuthorizationUpdate,
+		CustomizeDiff: customdiff.All(
+			orgCustomizeDiff,
+			denyPrivilegedAuthorization,
+			resourceAuthorizationCustomizeDiff,
+		),
+		ValidateRawResourceConfigFuncs: []schema.ValidateRawResourceConfigFunc{
+			warnPrivilegedAuthorization,
+		},
 		Schema: map[string]*schema.Schema{
+			"endpoint": endpointSchema(),
 			"org_id": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:        schema.TypeString,
+				Description: "Defaults to the organization of the provider.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"org": {
+				Type:          schema.TypeString,
+				Description:   "Name of the organization, resolved to `org_id`.",
+				Optional:      true,
+				ConflictsWith: []string{"org_id"},
 			},
 			"description": {
 				Type:     schema.TypeString,
@@ -46,7 +69,7 @@ func ResourceAuthorization() *schema.Resource {
 								Schema: map[string]*schema.Schema{
 									"id": {
 										Type:     schema.TypeString,
-										Required: true,
+										Optional: true,
 									},
 									"org": {
 										Type:     schema.TypeString,
@@ -79,14 +102,41 @@ func ResourceAuthorization() *schema.Resource {
 				Computed:  true,
 				Sensitive: true,
 			},
+			"rotate_when_changed": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"rotation_overlap_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"previous_authorization_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"rotated_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
 func resourceAuthorizationCreate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	permissions := getPermissions(d.Get("permissions"))
-	orgId := d.Get("org_id").(string)
+	orgId, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
 	description := d.Get("description").(string)
 	status := domain.AuthorizationUpdateRequestStatus(d.Get("status").(string))
 	authorizations := domain.Authorization{
@@ -100,9 +150,13 @@ func resourceAuthorizationCreate(d *schema.ResourceData, m interface{}) error {
 
 	result, err := influx.AuthorizationsAPI().CreateAuthorization(context.Background(), &authorizations)
 	if err != nil {
-		return fmt.Errorf("error creating authorization: %e", err)
+		return fmt.Errorf("error creating authorization: %v", err)
 	}
 	d.SetId(*result.Id)
+	err = d.Set("org_id", orgId)
+	if err != nil {
+		return err
+	}
 	err = d.Set("token", *result.Token)
 	if err != nil {
 		return err
@@ -111,20 +165,33 @@ func resourceAuthorizationCreate(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceAuthorizationDelete(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	id := d.Id()
 	authorization := domain.Authorization{
 		Id: &id,
 	}
-	err := influx.AuthorizationsAPI().DeleteAuthorization(context.Background(), &authorization)
+	err = influx.AuthorizationsAPI().DeleteAuthorization(context.Background(), &authorization)
 	if err != nil {
 		return fmt.Errorf("error deleting authorization: %v", err)
 	}
+	// A token still within its rotation overlap period would otherwise be orphaned
+	if previous := d.Get("previous_authorization_id").(string); previous != "" {
+		err = retireAuthorization(d, m, previous)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func resourceAuthorizationRead(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	result, err := influx.AuthorizationsAPI().FindAuthorizationsByOrgID(context.Background(), d.Get("org_id").(string))
 	if err != nil {
 		return fmt.Errorf("error getting authorization: %v", err)
@@ -158,19 +225,252 @@ func resourceAuthorizationRead(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceAuthorizationUpdate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	if d.HasChange("rotate_when_changed") {
+		return resourceAuthorizationRotate(d, m)
+	}
+
+	// The previous token is planned to be retired once its overlap period has
+	// elapsed. An empty planned value reads as the prior one here, so the
+	// overlap is checked again rather than comparing against the plan.
+	if previous := d.Get("previous_authorization_id").(string); previous != "" {
+		elapsed, err := rotationOverlapElapsed(d)
+		if err != nil {
+			return err
+		}
+		if elapsed {
+			err = retireAuthorization(d, m, previous)
+			if err != nil {
+				return err
+			}
+			err = d.Set("previous_authorization_id", "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	id := d.Id()
 	authorization := domain.Authorization{
 		Id: &id,
 	}
 	statusUpdate := domain.AuthorizationUpdateRequestStatus(d.Get("status").(string))
-	_, err := influx.AuthorizationsAPI().UpdateAuthorizationStatus(context.Background(), &authorization, statusUpdate)
+	_, err = influx.AuthorizationsAPI().UpdateAuthorizationStatus(context.Background(), &authorization, statusUpdate)
 	if err != nil {
 		return fmt.Errorf("error updating authorization: %v", err)
 	}
 	return resourceAuthorizationRead(d, m)
 }
 
+// resourceAuthorizationRotate creates a new token with the same permissions as the
+// current one, then either retires the current token immediately or keeps it
+// around as the previous token until the overlap period has elapsed.
+func resourceAuthorizationRotate(d *schema.ResourceData, m interface{}) error {
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+
+	// Only one previous token is tracked, so any token still within its
+	// overlap period is retired before another rotation takes place
+	previous, _ := d.GetChange("previous_authorization_id")
+	if previous.(string) != "" {
+		err := retireAuthorization(d, m, previous.(string))
+		if err != nil {
+			return err
+		}
+	}
+
+	permissions := getPermissions(d.Get("permissions"))
+	orgId := d.Get("org_id").(string)
+	description := d.Get("description").(string)
+	status := domain.AuthorizationUpdateRequestStatus(d.Get("status").(string))
+	authorizations := domain.Authorization{
+		AuthorizationUpdateRequest: domain.AuthorizationUpdateRequest{
+			Description: &(description),
+			Status:      &status,
+		},
+		OrgID:       &orgId,
+		Permissions: &permissions,
+	}
+	result, err := influx.AuthorizationsAPI().CreateAuthorization(context.Background(), &authorizations)
+	if err != nil {
+		return fmt.Errorf("error rotating authorization: %v", err)
+	}
+
+	current := d.Id()
+	d.SetId(*result.Id)
+	err = d.Set("token", *result.Token)
+	if err != nil {
+		return err
+	}
+	err = d.Set("rotated_at", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	if d.Get("rotation_overlap_seconds").(int) == 0 {
+		err = retireAuthorization(d, m, current)
+		if err != nil {
+			return err
+		}
+		current = ""
+	}
+	err = d.Set("previous_authorization_id", current)
+	if err != nil {
+		return err
+	}
+	return resourceAuthorizationRead(d, m)
+}
+
+func resourceAuthorizationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	// Nothing to rotate or retire until the authorization exists
+	if d.Id() == "" {
+		return nil
+	}
+
+	if d.HasChange("rotate_when_changed") {
+		for _, key := range []string{"token", "previous_authorization_id", "rotated_at", "user_id"} {
+			err := d.SetNewComputed(key)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Plan the retirement of the previous token once its overlap period has elapsed
+	if d.Get("previous_authorization_id").(string) == "" {
+		return nil
+	}
+	elapsed, err := rotationOverlapElapsed(d)
+	if err != nil {
+		return err
+	}
+	if elapsed {
+		return d.SetNew("previous_authorization_id", "")
+	}
+	return nil
+}
+
+// rotationOverlapElapsed tells whether the previous token has outlived its
+// overlap period since the last rotation.
+func rotationOverlapElapsed(d interface{ Get(string) interface{} }) (bool, error) {
+	rotatedAt, err := time.Parse(time.RFC3339, d.Get("rotated_at").(string))
+	if err != nil {
+		return false, fmt.Errorf("error reading rotated_at: %v", err)
+	}
+	overlap := time.Duration(d.Get("rotation_overlap_seconds").(int)) * time.Second
+	return time.Since(rotatedAt) >= overlap, nil
+}
+
+// retireAuthorization deletes a token that has been superseded by a rotation.
+// A token that is already gone is treated as retired.
+func retireAuthorization(d *schema.ResourceData, m interface{}, id string) error {
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	err = influx.AuthorizationsAPI().DeleteAuthorizationWithID(context.Background(), id)
+	if err != nil && !isNotFoundError(err) {
+		return fmt.Errorf("error retiring previous authorization %s: %v", id, err)
+	}
+	return nil
+}
+
+// privilegedResourceTypes are the types of resource that only operators of the
+// server should write, as writing them creates tokens, users or organizations
+// with any permission.
+var privilegedResourceTypes = []domain.ResourceType{
+	domain.ResourceTypeAuthorizations,
+	domain.ResourceTypeUsers,
+	domain.ResourceTypeOrgs,
+}
+
+// privilegedPermissions describes the permissions that write privileged
+// resource types, such as "write orgs".
+func privilegedPermissions(permissions []domain.Permission) []string {
+	var privileged []string
+	for _, resourceType := range privilegedResourceTypes {
+		for _, permission := range permissions {
+			if permission.Action == domain.PermissionActionWrite && permission.Resource.Type == resourceType {
+				privileged = append(privileged, fmt.Sprintf("write %s", resourceType))
+				break
+			}
+		}
+	}
+	return privileged
+}
+
+// warnPrivilegedAuthorization warns when planning an authorization with
+// privileged permissions. Permissions that are not known yet are left to
+// denyPrivilegedAuthorization.
+func warnPrivilegedAuthorization(ctx context.Context, req schema.ValidateResourceConfigFuncRequest, resp *schema.ValidateResourceConfigFuncResponse) {
+	if !req.RawConfig.IsKnown() || req.RawConfig.IsNull() {
+		return
+	}
+	config := req.RawConfig.GetAttr("permissions")
+	if !config.IsKnown() || config.IsNull() {
+		return
+	}
+	var permissions []domain.Permission
+	for it := config.ElementIterator(); it.Next(); {
+		_, permission := it.Element()
+		if !permission.IsKnown() || permission.IsNull() {
+			continue
+		}
+		action := permission.GetAttr("action")
+		resources := permission.GetAttr("resource")
+		if !action.IsKnown() || action.IsNull() || !resources.IsKnown() || resources.IsNull() {
+			continue
+		}
+		for rit := resources.ElementIterator(); rit.Next(); {
+			_, resource := rit.Element()
+			if !resource.IsKnown() || resource.IsNull() {
+				continue
+			}
+			resourceType := resource.GetAttr("type")
+			if !resourceType.IsKnown() || resourceType.IsNull() {
+				continue
+			}
+			permissions = append(permissions, domain.Permission{
+				Action:   domain.PermissionAction(action.AsString()),
+				Resource: domain.Resource{Type: domain.ResourceType(resourceType.AsString())},
+			})
+		}
+	}
+	privileged := privilegedPermissions(permissions)
+	if len(privileged) == 0 {
+		return
+	}
+	resp.Diagnostics = append(resp.Diagnostics, diag.Diagnostic{
+		Severity:      diag.Warning,
+		Summary:       "Privileged authorization",
+		Detail:        fmt.Sprintf("The authorization can %s, which lets its token give itself or others any permission, like an operator token. Grant only the permissions it needs, or set deny_privileged_tokens on the provider to refuse such authorizations.", strings.Join(privileged, ", ")),
+		AttributePath: cty.GetAttrPath("permissions"),
+	})
+}
+
+// denyPrivilegedAuthorization refuses to plan new privileged permissions when
+// the provider sets deny_privileged_tokens. Authorizations that already exist
+// are left alone until their permissions change.
+func denyPrivilegedAuthorization(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if m == nil || !m.(*meta).denyPrivilegedTokens {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChange("permissions") {
+		return nil
+	}
+	privileged := privilegedPermissions(getPermissions(d.Get("permissions")))
+	if len(privileged) > 0 {
+		return fmt.Errorf("the authorization can %s, which deny_privileged_tokens of the provider refuses", strings.Join(privileged, ", "))
+	}
+	return nil
+}
+
 func getPermissions(input interface{}) []domain.Permission {
 	result := []domain.Permission{}
 	permissionsSet := input.(*schema.Set).List()
@@ -193,7 +493,8 @@ func getPermissions(input interface{}) []domain.Permission {
 				if res["org"] != nil {
 					org = res["org"].(string)
 				}
-				Resource := domain.Resource{Type: domain.ResourceType(res["type"].(string)), Id: &id, OrgID: &org_id, Name: &name, Org: &org}
+				// Empty values are left out, so that an empty id gives every resource of the type
+				Resource := domain.Resource{Type: domain.ResourceType(res["type"].(string)), Id: optionalString(id), OrgID: optionalString(org_id), Name: optionalString(name), Org: optionalString(org)}
 				each := domain.Permission{Action: domain.PermissionAction(perm["action"].(string)), Resource: Resource}
 				result = append(result, each)
 			}
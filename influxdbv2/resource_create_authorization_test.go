@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 )
@@ -14,7 +18,7 @@ import (
 var authorizationIdOnCreate string
 
 func TestAccAuthorization(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
 		CheckDestroy: testAccAuthorizationDestroyed,
@@ -68,6 +72,116 @@ func TestAccAuthorization(t *testing.T) {
 	})
 }
 
+func TestAccAuthorizationRotation(t *testing.T) {
+	testFakeInfluxDB(t, func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+		authorization := func(version string, overlap int) string {
+			return config(fmt.Sprintf(`
+resource "influxdb-v2_authorization" "acctest" {
+	description = "Rotated token"
+	rotate_when_changed = {
+		version = "%s"
+	}
+	rotation_overlap_seconds = %d
+	permissions {
+		action = "read"
+		resource {
+			id = data.influxdb-v2_bucket.testbucket.id
+			org_id = data.influxdb-v2_bucket.testbucket.org_id
+			type = "buckets"
+		}
+	}
+}
+data "influxdb-v2_bucket" "testbucket" {
+	name = "testbucket"
+}
+`, version, overlap))
+		}
+		var first, second, third, fourth string
+		return []resource.TestStep{
+			{
+				Config: authorization("1", 0),
+				Check: resource.ComposeTestCheckFunc(
+					storeResourceID("influxdb-v2_authorization.acctest", &first),
+					resource.TestCheckNoResourceAttr("influxdb-v2_authorization.acctest", "previous_authorization_id"),
+					resource.TestCheckNoResourceAttr("influxdb-v2_authorization.acctest", "rotated_at"),
+				),
+			},
+			{
+				// Without an overlap the current token is retired right away
+				Config: authorization("2", 0),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceHasBeenReplaced("influxdb-v2_authorization.acctest", &first),
+					storeResourceID("influxdb-v2_authorization.acctest", &second),
+					resource.TestCheckResourceAttr("influxdb-v2_authorization.acctest", "previous_authorization_id", ""),
+					resource.TestCheckResourceAttrSet("influxdb-v2_authorization.acctest", "rotated_at"),
+					f.checkAuthorization(&first, false),
+					f.checkAuthorization(&second, true),
+				),
+			},
+			{
+				// With an overlap the current token becomes the previous one
+				Config: authorization("3", 3),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceHasBeenReplaced("influxdb-v2_authorization.acctest", &second),
+					storeResourceID("influxdb-v2_authorization.acctest", &third),
+					resource.TestCheckResourceAttrPtr("influxdb-v2_authorization.acctest", "previous_authorization_id", &second),
+					f.checkAuthorization(&second, true),
+					f.checkAuthorization(&third, true),
+				),
+			},
+			{
+				// The previous token is retired once the overlap has elapsed
+				// since the rotation, without changing the configuration
+				PreConfig: func() { time.Sleep(3 * time.Second) },
+				Config:    authorization("3", 3),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr("influxdb-v2_authorization.acctest", "id", &third),
+					resource.TestCheckResourceAttr("influxdb-v2_authorization.acctest", "previous_authorization_id", ""),
+					f.checkAuthorization(&second, false),
+					f.checkAuthorization(&third, true),
+				),
+			},
+			{
+				Config: authorization("4", 3600),
+				Check: resource.ComposeTestCheckFunc(
+					storeResourceID("influxdb-v2_authorization.acctest", &fourth),
+					resource.TestCheckResourceAttrPtr("influxdb-v2_authorization.acctest", "previous_authorization_id", &third),
+				),
+			},
+			{
+				// Shortening the overlap retires the previous token too
+				Config: authorization("4", 0),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr("influxdb-v2_authorization.acctest", "id", &fourth),
+					resource.TestCheckResourceAttr("influxdb-v2_authorization.acctest", "previous_authorization_id", ""),
+					f.checkAuthorization(&third, false),
+					f.checkAuthorization(&fourth, true),
+				),
+			},
+		}
+	})
+}
+
+// storeResourceID stores the id of a resource for later steps to compare with.
+func storeResourceID(name string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		*id = extractIdForResource(s, name)
+		return nil
+	}
+}
+
+// checkAuthorization checks whether the server still has an authorization.
+func (f *fakeInfluxDB) checkAuthorization(id *string, exists bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if _, ok := f.authorizations[*id]; ok != exists {
+			return fmt.Errorf("expected authorization %s to exist: %t", *id, exists)
+		}
+		return nil
+	}
+}
+
 func testAccCreateAuthorization() string {
 	return `
 resource "influxdb-v2_authorization" "acctest" {
@@ -144,3 +258,65 @@ func deleteAuth(id string) {
 		panic(fmt.Sprintf("Cannot delete authorization: %v", err))
 	}
 }
+
+func TestPrivilegedAuthorization(t *testing.T) {
+	authorization := ResourceAuthorization()
+	permission := func(action, resourceType string) map[string]interface{} {
+		return map[string]interface{}{
+			"action": action,
+			"resource": []interface{}{
+				map[string]interface{}{"id": "", "org_id": "org", "type": resourceType},
+			},
+		}
+	}
+	buckets := map[string]interface{}{
+		"org_id":      "org",
+		"permissions": []interface{}{permission("read", "orgs"), permission("write", "buckets")},
+	}
+	privileged := map[string]interface{}{
+		"org_id":      "org",
+		"permissions": []interface{}{permission("write", "users"), permission("write", "orgs"), permission("read", "authorizations")},
+	}
+
+	for _, c := range []struct {
+		config   map[string]interface{}
+		warnings int
+	}{{buckets, 0}, {privileged, 1}} {
+		resp := &schema.ValidateResourceConfigFuncResponse{}
+		req := schema.ValidateResourceConfigFuncRequest{RawConfig: permissionsConfig(c.config["permissions"].([]interface{}))}
+		warnPrivilegedAuthorization(context.Background(), req, resp)
+		if len(resp.Diagnostics) != c.warnings {
+			t.Fatalf("expected %d warnings for %v but got %v", c.warnings, c.config, resp.Diagnostics)
+		}
+	}
+
+	allow := &meta{defaultOrgID: "org"}
+	deny := &meta{defaultOrgID: "org", denyPrivilegedTokens: true}
+	if _, err := authorization.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(privileged), allow); err != nil {
+		t.Fatalf("expected privileged authorizations to be allowed by default but got %s", err)
+	}
+	if _, err := authorization.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(buckets), deny); err != nil {
+		t.Fatalf("expected authorizations without privileges to be allowed but got %s", err)
+	}
+	_, err := authorization.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(privileged), deny)
+	if err == nil || !strings.Contains(err.Error(), "write users, write orgs") {
+		t.Fatalf("expected privileged authorizations to be denied but got %v", err)
+	}
+}
+
+// permissionsConfig is the raw configuration of an authorization with the
+// permissions, of which only the action and type are set.
+func permissionsConfig(permissions []interface{}) cty.Value {
+	var values []cty.Value
+	for _, p := range permissions {
+		permission := p.(map[string]interface{})
+		resource := permission["resource"].([]interface{})[0].(map[string]interface{})
+		values = append(values, cty.ObjectVal(map[string]cty.Value{
+			"action": cty.StringVal(permission["action"].(string)),
+			"resource": cty.SetVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+				"type": cty.StringVal(resource["type"].(string)),
+			})}),
+		}))
+	}
+	return cty.ObjectVal(map[string]cty.Value{"permissions": cty.SetVal(values)})
+}
@@ -2,19 +2,27 @@ package influxdbv2
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
 func ResourceBucket() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceBucketCreate,
-		Delete: resourceBucketDelete,
-		Read:   resourceBucketRead,
-		Update: resourceBucketUpdate,
+		Create:        resourceBucketCreate,
+		Delete:        resourceBucketDelete,
+		Read:          resourceBucketRead,
+		Update:        resourceBucketUpdate,
+		CustomizeDiff: customdiff.All(orgCustomizeDiff, resourceBucketCustomizeDiff),
 		Schema: map[string]*schema.Schema{
+			"endpoint": endpointSchema(),
 			"description": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -23,23 +31,53 @@ func ResourceBucket() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			// org_id and rp force a new bucket unless the data is copied,
+			// see resourceBucketCustomizeDiff
 			"org_id": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:        schema.TypeString,
+				Description: "Defaults to the organization of the provider.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"org": {
+				Type:          schema.TypeString,
+				Description:   "Name of the organization, resolved to `org_id`.",
+				Optional:      true,
+				ConflictsWith: []string{"org_id"},
 			},
 			"retention_rules": {
 				Type:     schema.TypeSet,
 				Required: true,
+				Set:      hashRetentionRule,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"every_seconds": {
-							Type:     schema.TypeInt,
-							Required: true,
+							Type:        schema.TypeInt,
+							Description: "How long data is kept in seconds, 0 means forever.",
+							Optional:    true,
+							Computed:    true,
+						},
+						"every": {
+							Type:             schema.TypeString,
+							Description:      "How long data is kept as a duration such as `30d` or `1w`, instead of `every_seconds`.",
+							Optional:         true,
+							Computed:         true,
+							ValidateFunc:     validateRetentionDuration,
+							DiffSuppressFunc: suppressEquivalentDuration,
 						},
 						"shard_group_duration_seconds": {
-							Type:     schema.TypeInt,
-							Optional: true,
+							Type:        schema.TypeInt,
+							Description: "Shard group duration in seconds, read from the server when not set.",
+							Optional:    true,
+							Computed:    true,
+						},
+						"shard_group_duration": {
+							Type:             schema.TypeString,
+							Description:      "Shard group duration as a duration such as `1d`, instead of `shard_group_duration_seconds`.",
+							Optional:         true,
+							Computed:         true,
+							ValidateFunc:     validateRetentionDuration,
+							DiffSuppressFunc: suppressEquivalentDuration,
 						},
 						"type": {
 							Type:     schema.TypeString,
@@ -52,7 +90,19 @@ func ResourceBucket() *schema.Resource {
 			"rp": {
 				Type:     schema.TypeString,
 				Optional: true,
-				ForceNew: true,
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Description: "Refuses to delete or replace the bucket while true. Defaults to `true`.",
+				Optional:    true,
+				Default:     true,
+			},
+			"on_replace": {
+				Type:         schema.TypeString,
+				Description:  "What happens to the data when a change of `org_id` or `rp` replaces the bucket: `delete` it with the old bucket, or `copy` it to the new bucket with Flux before deleting the old one. Defaults to `delete`.",
+				Optional:     true,
+				Default:      "delete",
+				ValidateFunc: validation.StringInSlice([]string{"delete", "copy"}, false),
 			},
 			"created_at": {
 				Type:     schema.TypeString,
@@ -66,20 +116,93 @@ func ResourceBucket() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"effective_shard_group_duration": {
+				Type:        schema.TypeString,
+				Description: "The shard group duration used by the server, either the configured one or its default for the retention period.",
+				Computed:    true,
+			},
+			// The mapping follows the bucket when it is replaced
+			"dbrp": {
+				Type:        schema.TypeList,
+				Description: "DBRP mapping of the bucket for InfluxQL clients.",
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"database": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"retention_policy": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"default": {
+							Type:        schema.TypeBool,
+							Description: "Whether the mapping is the default retention policy of the database, demoting the previous default. Left to the server when not set.",
+							Optional:    true,
+							Computed:    true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
 func resourceBucketCreate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	id, err := createBucket(d, m)
+	if err != nil {
+		return err
+	}
 
-	retentionRules, err := getRetentionRules(d.Get("retention_rules"))
+	// A bucket without its mapping would break InfluxQL clients, so it is only
+	// kept when the mapping is created too
+	influx, err := m.(*meta).client(d)
 	if err != nil {
 		return err
 	}
+	ctx := context.Background()
+	orgID, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
+	err = createBucketDBRP(ctx, d, influx, orgID, id)
+	if err != nil {
+		if deleteErr := influx.BucketsAPI().DeleteBucketWithID(ctx, id); deleteErr != nil {
+			return errors.Join(err, fmt.Errorf("error deleting bucket %s: %v", id, deleteErr))
+		}
+		return err
+	}
+	d.SetId(id)
+	return resourceBucketRead(d, m)
+}
+
+// createBucket creates a bucket from the configuration and returns its ID.
+func createBucket(d *schema.ResourceData, m interface{}) (string, error) {
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return "", err
+	}
+
+	server, err := m.(*meta).getServer(d)
+	if err != nil {
+		return "", err
+	}
+	retentionRules, err := getRetentionRules(d.Get("retention_rules"), server)
+	if err != nil {
+		return "", err
+	}
 
 	desc := d.Get("description").(string)
-	oid := d.Get("org_id").(string)
+	oid, err := getOrgID(d, m)
+	if err != nil {
+		return "", err
+	}
 	rp := d.Get("rp").(string)
 	newBucket := &domain.Bucket{
 		Description:    &desc,
@@ -90,15 +213,28 @@ func resourceBucketCreate(d *schema.ResourceData, m interface{}) error {
 	}
 	result, err := influx.BucketsAPI().CreateBucket(context.Background(), newBucket)
 	if err != nil {
-		return fmt.Errorf("error creating bucket: %v", err)
+		return "", fmt.Errorf("error creating bucket: %v", err)
 	}
-	d.SetId(*result.Id)
-	return resourceBucketRead(d, m)
+	return *result.Id, nil
 }
 
 func resourceBucketDelete(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
-	err := influx.BucketsAPI().DeleteBucketWithID(context.Background(), d.Id())
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("bucket %s is protected from deletion, set deletion_protection to false and apply before deleting or replacing it", d.Get("name").(string))
+	}
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	// The server keeps the mapping of a deleted bucket
+	if block := bucketDBRP(d.Get("dbrp")); block != nil && block["id"].(string) != "" {
+		err = deleteBucketDBRP(ctx, influx, d.Get("org_id").(string), block["id"].(string))
+		if err != nil {
+			return err
+		}
+	}
+	err = influx.BucketsAPI().DeleteBucketWithID(ctx, d.Id())
 	if err != nil {
 		return fmt.Errorf("error deleting bucket: %v", err)
 	}
@@ -107,10 +243,18 @@ func resourceBucketDelete(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceBucketRead(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 
-	// Get user provided retention rules
-	providedRR := d.Get("retention_rules").(*schema.Set).List()
+	server, err := m.(*meta).getServer(d)
+	if err != nil {
+		return err
+	}
+
+	// Retention rules as they were written, to keep the way they are written
+	written := d.Get("retention_rules").(*schema.Set).List()
 
 	result, err := influx.BucketsAPI().FindBucketByID(context.Background(), d.Id())
 	if err != nil {
@@ -122,39 +266,22 @@ func resourceBucketRead(d *schema.ResourceData, m interface{}) error {
 		return fmt.Errorf("error getting bucket: %v", err)
 	}
 
-	// Reformat retention rules array
+	// The server is the source of truth. A bucket that keeps data forever may
+	// have no retention rule, which is the same as a rule of 0 seconds.
+	apiRules := result.RetentionRules
+	if len(apiRules) == 0 {
+		apiRules = domain.RetentionRules{{EverySeconds: 0}}
+	}
 	var rr []map[string]interface{}
-	for i, api := range result.RetentionRules {
-		tmp := map[string]interface{}{
-			"every_seconds": api.EverySeconds,
-			"type":          api.Type,
-		}
-		// Get user provided retention rules
-		provided, ok := providedRR[i].(map[string]interface{})
-		if !ok {
-			return fmt.Errorf("error reading user provided retention rules")
-		}
-		// If the user provided shard_group_duration_seconds, return the value from the API so that
-		// Terraform can produce a plan against it. If not, don't return because this is an optional
-		// property. It shouldn't be present if not declared. When creating/updating we make sure it
-		// is set to the default value when not provided by the user.
-		if provided["shard_group_duration_seconds"].(int) > 0 {
-			tmp["shard_group_duration_seconds"] = int(*api.ShardGroupDurationSeconds)
-		}
-		// -1 is a flag that signals the user does not wish for the shard group
-		// duration to be managed in any way by the provider
-		if provided["shard_group_duration_seconds"].(int) == -1 {
-			tmp["shard_group_duration_seconds"] = -1
-		}
-		rr = append(rr, tmp)
+	var effectiveShardGroupDuration int64
+	for _, api := range apiRules {
+		rule, shard := flattenRetentionRule(api, findWrittenRetentionRule(written, api.EverySeconds), server)
+		rr = append(rr, rule)
+		effectiveShardGroupDuration = shard
 	}
-	if len(result.RetentionRules) == 0 {
-		// If no retention rules, there's a default of 0 expiry
-		// but this isn't returned on the API
-		rr = append(rr, map[string]interface{}{
-			"every_seconds": 0,
-			"type":          "expire",
-		})
+	err = d.Set("effective_shard_group_duration", formatDuration(effectiveShardGroupDuration))
+	if err != nil {
+		return err
 	}
 
 	err = d.Set("name", result.Name)
@@ -190,14 +317,36 @@ func resourceBucketRead(d *schema.ResourceData, m interface{}) error {
 		return err
 	}
 
+	if block := bucketDBRP(d.Get("dbrp")); block != nil && block["id"].(string) != "" {
+		dbrps, err := readBucketDBRP(influx, *result.OrgID, block["id"].(string))
+		if err != nil {
+			return err
+		}
+		err = d.Set("dbrp", dbrps)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func resourceBucketUpdate(d *schema.ResourceData, m interface{}) error {
-	var err error
-	influx := m.(meta).influxsdk
+	// Only buckets whose data is copied get here with a new org_id or rp
+	if d.HasChanges("org_id", "rp") {
+		return resourceBucketCopy(d, m)
+	}
 
-	retentionRules, err := getRetentionRules(d.Get("retention_rules"))
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	server, err := m.(*meta).getServer(d)
+	if err != nil {
+		return err
+	}
+
+	retentionRules, err := getRetentionRules(d.Get("retention_rules"), server)
 	if err != nil {
 		return err
 	}
@@ -219,11 +368,19 @@ func resourceBucketUpdate(d *schema.ResourceData, m interface{}) error {
 	if err != nil {
 		return fmt.Errorf("error updating bucket: %v", err)
 	}
+	if d.HasChange("dbrp") {
+		err = updateBucketDBRP(context.Background(), d, influx, oid, id)
+		if err != nil {
+			return err
+		}
+	}
 
 	return resourceBucketRead(d, m)
 }
 
-func getRetentionRules(input interface{}) (domain.RetentionRules, error) {
+// getRetentionRules builds the retention rules sent to the server. Shard group
+// durations are left out for servers that don't support them.
+func getRetentionRules(input interface{}, server serverInfo) (domain.RetentionRules, error) {
 	result := domain.RetentionRules{}
 	provided := input.(*schema.Set).List()
 	for _, raw := range provided {
@@ -233,25 +390,28 @@ func getRetentionRules(input interface{}) (domain.RetentionRules, error) {
 		if !ok {
 			return nil, fmt.Errorf("Error reading retention rules")
 		}
+		every, sgsecs := retentionRuleSeconds(rr)
 		each := domain.RetentionRule{
-			EverySeconds: int64(rr["every_seconds"].(int)),
+			EverySeconds: every,
 			Type:         &defaultType,
 		}
 
-		sgsecs, ok := rr["shard_group_duration_seconds"].(int)
-		if !ok {
-			return nil, fmt.Errorf("Error reading retention rules duration")
+		if sgsecs > 0 && !server.cloud {
+			err := server.requireVersion("shard group durations", shardGroupDurationVersion)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// -1 is a flag that signals the user does not wish for the shard group
 		// duration to be managed in any way by the provider
-		if sgsecs == -1 {
+		if sgsecs == -1 || !supportsShardGroupDuration(server) {
 			result = append(result, each)
 			continue
 		}
 
 		if sgsecs > 0 {
-			v := int64(sgsecs)
+			v := sgsecs
 			each.ShardGroupDurationSeconds = &v
 			result = append(result, each)
 			continue
@@ -266,11 +426,437 @@ func getRetentionRules(input interface{}) (domain.RetentionRules, error) {
 	return result, nil
 }
 
+// resourceBucketCopy replaces a bucket with a new one and copies its data over
+// with Flux, before deleting it. The old bucket is renamed while the new one
+// exists when both are in the same organization, as bucket names are unique in
+// an organization. When the copy fails the old bucket is left as it was.
+func resourceBucketCopy(d *schema.ResourceData, m interface{}) error {
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	oldID := d.Id()
+	oldOrgID, _ := d.GetChange("org_id")
+	old, err := influx.BucketsAPI().FindBucketByID(ctx, oldID)
+	if err != nil {
+		return fmt.Errorf("error getting bucket: %v", err)
+	}
+	name := old.Name
+
+	newOrgID, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
+	renamed := newOrgID == oldOrgID.(string) && d.Get("name").(string) == name
+	if renamed {
+		old.Name = fmt.Sprintf("%s-replaced-%s", name, oldID)
+		old, err = influx.BucketsAPI().UpdateBucket(ctx, old)
+		if err != nil {
+			return fmt.Errorf("error renaming bucket before copying it: %v", err)
+		}
+	}
+	restore := func(cause error) error {
+		if renamed {
+			old.Name = name
+			if _, err := influx.BucketsAPI().UpdateBucket(ctx, old); err != nil {
+				return errors.Join(cause, fmt.Errorf("error restoring the name of bucket %s: %v", oldID, err))
+			}
+		}
+		return cause
+	}
+
+	newID, err := createBucket(d, m)
+	if err != nil {
+		return restore(err)
+	}
+	query := fmt.Sprintf(`from(bucketID: %q)
+  |> range(start: 0, stop: 2200-01-01T00:00:00Z)
+  |> to(bucketID: %q, orgID: %q)
+  |> keep(columns: ["_time"])
+  |> group()
+  |> count(column: "_time")`, oldID, newID, newOrgID)
+	result, err := influx.QueryAPI(oldOrgID.(string)).Query(withoutRequestTimeout(ctx), query)
+	if err == nil {
+		for result.Next() {
+			log.Printf("[INFO] copied %v points from bucket %s to %s", result.Record().ValueByKey("_time"), oldID, newID)
+		}
+		err = result.Err()
+		result.Close()
+	}
+	if err != nil {
+		err = fmt.Errorf("error copying bucket %s to %s: %v", oldID, newID, err)
+		if deleteErr := influx.BucketsAPI().DeleteBucketWithID(ctx, newID); deleteErr != nil {
+			err = errors.Join(err, fmt.Errorf("error deleting bucket %s: %v", newID, deleteErr))
+		}
+		return restore(err)
+	}
+
+	d.SetId(newID)
+	oldDBRP, _ := d.GetChange("dbrp")
+	if block := bucketDBRP(oldDBRP); block != nil && block["id"].(string) != "" {
+		err = deleteBucketDBRP(ctx, influx, oldOrgID.(string), block["id"].(string))
+		if err != nil {
+			return err
+		}
+	}
+	err = createBucketDBRP(ctx, d, influx, newOrgID, newID)
+	if err != nil {
+		return err
+	}
+	err = influx.BucketsAPI().DeleteBucketWithID(ctx, oldID)
+	if err != nil {
+		return fmt.Errorf("error deleting bucket %s after copying it: %v", oldID, err)
+	}
+	return resourceBucketRead(d, m)
+}
+
+// bucketDBRP returns the dbrp block of a bucket, or nil when it has none.
+func bucketDBRP(v interface{}) map[string]interface{} {
+	blocks, _ := v.([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	return blocks[0].(map[string]interface{})
+}
+
+// configuredBucketDBRPDefault returns the default of the dbrp block when it is
+// configured, as it is computed otherwise.
+func configuredBucketDBRPDefault(d *schema.ResourceData) *bool {
+	config := d.GetRawConfig()
+	if config.IsNull() {
+		return nil
+	}
+	blocks := config.GetAttr("dbrp")
+	if !blocks.IsKnown() || blocks.IsNull() || blocks.LengthInt() == 0 {
+		return nil
+	}
+	v := blocks.Index(cty.NumberIntVal(0)).GetAttr("default")
+	if !v.IsKnown() || v.IsNull() {
+		return nil
+	}
+	value := v.True()
+	return &value
+}
+
+// createBucketDBRP creates the mapping of the dbrp block of a bucket, if any.
+func createBucketDBRP(ctx context.Context, d *schema.ResourceData, influx influxdb2.Client, orgID, bucketID string) error {
+	block := bucketDBRP(d.Get("dbrp"))
+	if block == nil {
+		return nil
+	}
+	database := block["database"].(string)
+	isDefault := configuredBucketDBRPDefault(d)
+	var demoted []string
+	if isDefault != nil && *isDefault {
+		var err error
+		demoted, err = demoteDefaultDBRPs(ctx, influx, orgID, database, "")
+		if err != nil {
+			return err
+		}
+	}
+	dbrp, err := influx.APIClient().PostDBRP(ctx, &domain.PostDBRPAllParams{
+		Body: domain.PostDBRPJSONRequestBody{
+			BucketID:        bucketID,
+			Database:        database,
+			RetentionPolicy: block["retention_policy"].(string),
+			OrgID:           &orgID,
+			Default:         isDefault,
+		}})
+	if err != nil {
+		return restoreDefaultDBRPs(ctx, influx, orgID, demoted, fmt.Errorf("error creating dbrp mapping of bucket %s: %v", bucketID, err))
+	}
+	block["id"] = dbrp.Id
+	block["default"] = dbrp.Default
+	return d.Set("dbrp", []interface{}{block})
+}
+
+// updateBucketDBRP applies a change of the dbrp block of a bucket. Mappings
+// cannot move to another database, they are replaced instead.
+func updateBucketDBRP(ctx context.Context, d *schema.ResourceData, influx influxdb2.Client, orgID, bucketID string) error {
+	o, n := d.GetChange("dbrp")
+	current, wanted := bucketDBRP(o), bucketDBRP(n)
+	if current == nil || current["id"].(string) == "" {
+		return createBucketDBRP(ctx, d, influx, orgID, bucketID)
+	}
+	id := current["id"].(string)
+	if wanted == nil || wanted["database"] != current["database"] {
+		err := deleteBucketDBRP(ctx, influx, orgID, id)
+		if err != nil {
+			return err
+		}
+		return createBucketDBRP(ctx, d, influx, orgID, bucketID)
+	}
+
+	rp := wanted["retention_policy"].(string)
+	var isDefault *bool
+	var demoted []string
+	if d.HasChange("dbrp.0.default") {
+		value := wanted["default"].(bool)
+		isDefault = &value
+		if value {
+			var err error
+			demoted, err = demoteDefaultDBRPs(ctx, influx, orgID, wanted["database"].(string), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err := influx.APIClient().PatchDBRPID(ctx, &domain.PatchDBRPIDAllParams{
+		PatchDBRPIDParams: domain.PatchDBRPIDParams{
+			OrgID: &orgID,
+		},
+		DbrpID: id,
+		Body: domain.PatchDBRPIDJSONRequestBody{
+			RetentionPolicy: &rp,
+			Default:         isDefault,
+		},
+	})
+	if err != nil {
+		return restoreDefaultDBRPs(ctx, influx, orgID, demoted, fmt.Errorf("error updating dbrp mapping of bucket %s: %v", bucketID, err))
+	}
+	return nil
+}
+
+// readBucketDBRP reads the mapping of a bucket into its dbrp block, which is
+// empty when the mapping is gone so that it is created again.
+func readBucketDBRP(influx influxdb2.Client, orgID, id string) ([]interface{}, error) {
+	dbrp, err := influx.APIClient().GetDBRPsID(context.Background(), &domain.GetDBRPsIDAllParams{
+		DbrpID: id,
+		GetDBRPsIDParams: domain.GetDBRPsIDParams{
+			OrgID: &orgID,
+		},
+	})
+	if err != nil {
+		notFoundError := "not found: unable to find DBRP"
+		if err.Error() == notFoundError {
+			return []interface{}{}, nil
+		}
+		return nil, fmt.Errorf("error getting dbrp mapping of bucket: %v", err)
+	}
+	return []interface{}{map[string]interface{}{
+		"id":               dbrp.Content.Id,
+		"database":         dbrp.Content.Database,
+		"retention_policy": dbrp.Content.RetentionPolicy,
+		"default":          dbrp.Content.Default,
+	}}, nil
+}
+
+func deleteBucketDBRP(ctx context.Context, influx influxdb2.Client, orgID, id string) error {
+	err := influx.APIClient().DeleteDBRPID(ctx, &domain.DeleteDBRPIDAllParams{
+		DbrpID: id,
+		DeleteDBRPIDParams: domain.DeleteDBRPIDParams{
+			OrgID: &orgID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting dbrp mapping %s: %v", id, err)
+	}
+	return nil
+}
+
+// flattenRetentionRule turns a retention rule of the server into state, along
+// with the shard group duration in effect. The shard group duration is always stored so
+// that changes made elsewhere show up as drift, and durations are written the
+// way they were configured.
+func flattenRetentionRule(api domain.RetentionRule, written map[string]interface{}, server serverInfo) (map[string]interface{}, int64) {
+	ruleType := "expire"
+	if api.Type != nil {
+		ruleType = string(*api.Type)
+	}
+	shard := getDefaultShardGroupDuration(api.EverySeconds)
+	if api.ShardGroupDurationSeconds != nil && *api.ShardGroupDurationSeconds > 0 {
+		shard = *api.ShardGroupDurationSeconds
+	}
+	effective := shard
+	_, writtenShard := retentionRuleSeconds(written)
+	// Servers that don't manage shard group durations keep the configured one
+	if !supportsShardGroupDuration(server) {
+		shard = writtenShard
+		if shard < 0 {
+			shard = 0
+		}
+	}
+	writtenEvery, _ := written["every"].(string)
+	writtenShardDuration, _ := written["shard_group_duration"].(string)
+	rule := map[string]interface{}{
+		"every_seconds":                int(api.EverySeconds),
+		"every":                        keepDuration(writtenEvery, api.EverySeconds),
+		"shard_group_duration_seconds": int(shard),
+		"shard_group_duration":         keepDuration(writtenShardDuration, shard),
+		"type":                         ruleType,
+	}
+	// -1 is a flag that signals the user does not wish for the shard group
+	// duration to be managed in any way by the provider
+	if writtenShard == -1 {
+		rule["shard_group_duration_seconds"] = -1
+	}
+	return rule, effective
+}
+
+// findWrittenRetentionRule returns the configured rule that keeps data for as
+// long as a rule of the server, or the only configured rule.
+func findWrittenRetentionRule(written []interface{}, everySeconds int64) map[string]interface{} {
+	for _, raw := range written {
+		rule, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if every, _ := retentionRuleSeconds(rule); every == everySeconds {
+			return rule
+		}
+	}
+	if len(written) == 1 {
+		if rule, ok := written[0].(map[string]interface{}); ok {
+			return rule
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// retentionRuleSeconds returns how long a retention rule keeps data and its
+// shard group duration, whether they are given in seconds or as durations.
+func retentionRuleSeconds(rule map[string]interface{}) (int64, int64) {
+	every, _ := rule["every_seconds"].(int)
+	everySeconds := int64(every)
+	if value, _ := rule["every"].(string); value != "" {
+		everySeconds, _ = parseDuration(value)
+	}
+	shard, _ := rule["shard_group_duration_seconds"].(int)
+	shardSeconds := int64(shard)
+	if value, _ := rule["shard_group_duration"].(string); value != "" {
+		shardSeconds, _ = parseDuration(value)
+	}
+	return everySeconds, shardSeconds
+}
+
+// hashRetentionRule identifies a retention rule by what it does rather than
+// how it is written, so that 30d and 2592000 seconds are the same rule. The
+// shard group duration is left out as it is computed when not configured, a
+// change to it is an update of the rule.
+func hashRetentionRule(v interface{}) int {
+	rule := v.(map[string]interface{})
+	every, _ := retentionRuleSeconds(rule)
+	ruleType, _ := rule["type"].(string)
+	return schema.HashString(fmt.Sprintf("%d-%s", every, ruleType))
+}
+
+// keepDuration returns the duration as it was written when it is still the
+// given number of seconds, or else the seconds written as a duration.
+func keepDuration(written string, seconds int64) string {
+	if written != "" {
+		if parsed, err := parseDuration(written); err == nil && parsed == seconds {
+			return written
+		}
+	}
+	return formatDuration(seconds)
+}
+
+// resourceBucketCustomizeDiff checks that each retention rule is given once,
+// either in seconds or as a duration, and that its shard group duration fits
+// in its retention period.
+func resourceBucketCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	// Moving a bucket to another organization or retention policy needs a new
+	// bucket, which is an update when the data is copied into it
+	if d.Id() != "" && d.Get("on_replace").(string) != "copy" {
+		for _, key := range []string{"org_id", "rp"} {
+			if d.HasChange(key) {
+				err := d.ForceNew(key)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if old, new := d.GetChange("retention_rules"); retentionRulesChanged(old.(*schema.Set), new.(*schema.Set)) {
+		err := d.SetNewComputed("effective_shard_group_duration")
+		if err != nil {
+			return err
+		}
+	}
+
+	rules := d.GetRawConfig().GetAttr("retention_rules")
+	if rules.IsNull() || !rules.IsWhollyKnown() {
+		return nil
+	}
+	for it := rules.ElementIterator(); it.Next(); {
+		_, rule := it.Element()
+		every := rule.GetAttr("every")
+		everySeconds := rule.GetAttr("every_seconds")
+		if !every.IsNull() && !everySeconds.IsNull() {
+			return fmt.Errorf("retention rules take either every or every_seconds, not both")
+		}
+		if every.IsNull() && everySeconds.IsNull() {
+			return fmt.Errorf("retention rules need one of every or every_seconds")
+		}
+		if !rule.GetAttr("shard_group_duration").IsNull() && !rule.GetAttr("shard_group_duration_seconds").IsNull() {
+			return fmt.Errorf("retention rules take either shard_group_duration or shard_group_duration_seconds, not both")
+		}
+
+		retention, shard := retentionRuleSeconds(map[string]interface{}{
+			"every_seconds":                ctyInt(everySeconds),
+			"every":                        ctyString(every),
+			"shard_group_duration_seconds": ctyInt(rule.GetAttr("shard_group_duration_seconds")),
+			"shard_group_duration":         ctyString(rule.GetAttr("shard_group_duration")),
+		})
+		if retention > 0 && shard > retention {
+			return fmt.Errorf("shard group duration %s is longer than the retention period %s", formatDuration(shard), formatDuration(retention))
+		}
+	}
+	return nil
+}
+
+// retentionRulesChanged tells whether the retention periods or the configured
+// shard group durations of the rules changed, a shard group duration of -1
+// being left to the server. Attributes of the rules that are
+// only computed differ between the state and the plan without any change.
+func retentionRulesChanged(old, new *schema.Set) bool {
+	if old.Len() != new.Len() {
+		return true
+	}
+	for _, rule := range new.List() {
+		every, shard := retentionRuleSeconds(rule.(map[string]interface{}))
+		found := false
+		for _, oldRule := range old.List() {
+			oldEvery, oldShard := retentionRuleSeconds(oldRule.(map[string]interface{}))
+			found = found || (every == oldEvery && (shard <= 0 || shard == oldShard))
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
+func ctyInt(v cty.Value) int {
+	if v.IsNull() {
+		return 0
+	}
+	i, _ := v.AsBigFloat().Int64()
+	return int(i)
+}
+
+func ctyString(v cty.Value) string {
+	if v.IsNull() {
+		return ""
+	}
+	return v.AsString()
+}
+
+// InfluxDB Cloud manages shard group durations itself
+func supportsShardGroupDuration(server serverInfo) bool {
+	return !server.cloud && server.atLeast(shardGroupDurationVersion)
+}
+
 // https://docs.influxdata.com/influxdb/v2/reference/internals/shards/#shard-group-duration
 func getDefaultShardGroupDuration(rps int64) int64 {
 	hour := int64(60 * 60)
 	day := hour * 24
 	month := 180 * day
+	// Data that is kept forever is sharded like long retention periods
+	if rps == 0 {
+		return 7 * day
+	}
 	if rps < 2*day {
 		return 1 * hour
 	}
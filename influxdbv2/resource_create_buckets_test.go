@@ -4,17 +4,22 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
 var bucketIdOnCreate string
 
 func TestAccCreateBucket(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
 		CheckDestroy: testAccBucketDestroyed,
@@ -112,9 +117,11 @@ func TestAccCreateBucket(t *testing.T) {
 						"retention_rules.0.every_seconds",
 						"3630",
 					),
-					resource.TestCheckNoResourceAttr(
+					// The default shard group duration of the server is stored
+					resource.TestCheckResourceAttr(
 						"influxdb-v2_bucket.acctest",
 						"retention_rules.0.shard_group_duration_seconds",
+						"3600",
 					),
 					resource.TestCheckResourceAttrSet(
 						"influxdb-v2_bucket.acctest",
@@ -183,6 +190,332 @@ func TestAccCreateBucket(t *testing.T) {
 	})
 }
 
+func TestAccCreateBucketDefaultOrg(t *testing.T) {
+	var id string
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccBucketDestroyed,
+		Steps: []resource.TestStep{
+			{
+				// The provider's org_id defaults to INFLUXDB_V2_ORG_ID
+				Config: testAccCreateBucketWithOrg(""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+					func(s *terraform.State) error {
+						id = extractIdForResource(s, "influxdb-v2_bucket.acctest")
+						return nil
+					},
+				),
+			},
+			{
+				// Naming the same organization must not replace the bucket
+				Config: testAccCreateBucketWithOrg(`org = "testorg"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+					resource.TestCheckResourceAttrPtr("influxdb-v2_bucket.acctest", "id", &id),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCreateBucketProviderOrg(t *testing.T) {
+	testAccPreCheck(t)
+	// Without an org_id the provider uses its org, which this apply creates
+	t.Setenv("INFLUXDB_V2_ORG_ID", "")
+	testAccRun(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccBucketDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+provider "influxdb-v2" {
+    org = "acctest-provider-org"
+}
+
+resource "influxdb-v2_organization" "acctest" {
+    name = "acctest-provider-org"
+}
+
+resource "influxdb-v2_bucket" "acctest" {
+    name = "acctest"
+    deletion_protection = false
+    retention_rules {
+        every_seconds = "3640"
+    }
+    depends_on = [influxdb-v2_organization.acctest]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("influxdb-v2_bucket.acctest", "org_id", "influxdb-v2_organization.acctest", "id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCreateBucketWithOrg(org string) string {
+	return `
+resource "influxdb-v2_bucket" "acctest" {
+    name = "acctest"
+    deletion_protection = false
+    ` + org + `
+    retention_rules {
+        every_seconds = "3640"
+    }
+}
+`
+}
+
+func TestAccCreateBucketDurations(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccBucketDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCreateBucketWithDurations("1w", "1d"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "retention_rules.0.every", "1w"),
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "retention_rules.0.every_seconds", "604800"),
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "effective_shard_group_duration", "1d"),
+				),
+			},
+			{
+				// The same rule written in hours must not change the bucket
+				Config:   testAccCreateBucketWithDurations("168h", "24h"),
+				PlanOnly: true,
+			},
+			{
+				Config:      testAccCreateBucketWithDurations("1d", "1w"),
+				ExpectError: regexp.MustCompile("shard group duration 1w is longer than the retention period 1d"),
+			},
+		},
+	})
+}
+
+func testAccCreateBucketWithDurations(every string, shard string) string {
+	return `
+resource "influxdb-v2_bucket" "acctest" {
+    name = "acctest"
+    deletion_protection = false
+    org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+    retention_rules {
+        every = "` + every + `"
+        shard_group_duration = "` + shard + `"
+    }
+}
+`
+}
+
+func TestFlattenRetentionRule(t *testing.T) {
+	expire := domain.RetentionRuleTypeExpire
+	shard := int64(86400)
+	api := domain.RetentionRule{EverySeconds: 2592000, ShardGroupDurationSeconds: &shard, Type: &expire}
+
+	// Nothing was written after an import, the server's values are stored
+	rule, effective := flattenRetentionRule(api, findWrittenRetentionRule(nil, api.EverySeconds), serverInfo{})
+	if rule["every_seconds"] != 2592000 || rule["every"] != "30d" || rule["shard_group_duration_seconds"] != 86400 || effective != 86400 {
+		t.Fatalf("unexpected rule %v with shard group duration %d", rule, effective)
+	}
+
+	// Durations keep the way they were written
+	written := []interface{}{map[string]interface{}{"every": "720h", "shard_group_duration": "24h"}}
+	rule, _ = flattenRetentionRule(api, findWrittenRetentionRule(written, api.EverySeconds), serverInfo{})
+	if rule["every"] != "720h" || rule["shard_group_duration"] != "24h" {
+		t.Fatalf("expected durations to be kept as written but got %v", rule)
+	}
+
+	// Data kept forever is sharded weekly by default
+	rule, effective = flattenRetentionRule(domain.RetentionRule{}, map[string]interface{}{}, serverInfo{})
+	if rule["every_seconds"] != 0 || rule["type"] != "expire" || effective != 604800 {
+		t.Fatalf("unexpected rule %v with shard group duration %d", rule, effective)
+	}
+
+	// More rules on the server than configured don't need a configured match
+	if rule := findWrittenRetentionRule(append(written, written...), 1); len(rule) != 0 {
+		t.Fatalf("expected no written rule but got %v", rule)
+	}
+}
+
+func TestAccCreateBucketCopyOnReplace(t *testing.T) {
+	var oldID string
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccBucketDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCreateBucketCopied(""),
+				Check: func(s *terraform.State) error {
+					oldID = extractIdForResource(s, "influxdb-v2_bucket.acctest")
+					return nil
+				},
+			},
+			{
+				// A new retention policy makes a new bucket with the same name,
+				// into which the data of the old one is copied
+				PreConfig: func() {
+					writeCopyOnReplacePoints(oldID, 3)
+				},
+				Config: testAccCreateBucketCopied("autogen"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "name", "acctest"),
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "rp", "autogen"),
+					func(s *terraform.State) error {
+						if id := extractIdForResource(s, "influxdb-v2_bucket.acctest"); id == oldID {
+							return fmt.Errorf("expected a new bucket but kept %s", id)
+						}
+						return nil
+					},
+					testAccCheckCopyOnReplacePoints("influxdb-v2_bucket.acctest", 3),
+				),
+			},
+		},
+	})
+}
+
+// writeCopyOnReplacePoints writes points of distinct hosts into a bucket.
+func writeCopyOnReplacePoints(bucketID string, n int) {
+	influx := influxdb2.NewClient(
+		os.Getenv("INFLUXDB_V2_URL"),
+		os.Getenv("INFLUXDB_V2_TOKEN"),
+	)
+	write := influx.WriteAPIBlocking(os.Getenv("INFLUXDB_V2_ORG_ID"), bucketID)
+	for i := 0; i < n; i++ {
+		p := influxdb2.NewPoint("acctest_copy", map[string]string{"host": fmt.Sprint(i)}, map[string]interface{}{"value": i}, time.Now())
+		if err := write.WritePoint(context.Background(), p); err != nil {
+			panic("Cannot write point")
+		}
+	}
+}
+
+// testAccCheckCopyOnReplacePoints counts the points copied into a bucket.
+func testAccCheckCopyOnReplacePoints(name string, want int64) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		influx := influxdb2.NewClient(
+			os.Getenv("INFLUXDB_V2_URL"),
+			os.Getenv("INFLUXDB_V2_TOKEN"),
+		)
+		query := fmt.Sprintf(`from(bucketID: %q)
+  |> range(start: 0)
+  |> filter(fn: (r) => r._measurement == "acctest_copy")
+  |> group()
+  |> count()`, extractIdForResource(s, name))
+		result, err := influx.QueryAPI(os.Getenv("INFLUXDB_V2_ORG_ID")).Query(context.Background(), query)
+		if err != nil {
+			return fmt.Errorf("Cannot query points: %v", err)
+		}
+		var count int64
+		for result.Next() {
+			count += result.Record().Value().(int64)
+		}
+		if result.Err() != nil {
+			return result.Err()
+		}
+		if count != want {
+			return fmt.Errorf("expected %d points to be copied but got %d", want, count)
+		}
+		return nil
+	}
+}
+
+func testAccCreateBucketCopied(rp string) string {
+	return `
+resource "influxdb-v2_bucket" "acctest" {
+    name = "acctest"
+    deletion_protection = false
+    on_replace = "copy"
+    org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+    rp = "` + rp + `"
+    retention_rules {
+        every_seconds = 0
+    }
+}
+`
+}
+
+func TestAccCreateBucketDBRP(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccBucketDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCreateBucketWithDBRP(""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("influxdb-v2_bucket.acctest", "dbrp.0.id"),
+					resource.TestCheckResourceAttr("influxdb-v2_bucket.acctest", "dbrp.0.default", "true"),
+					testAccCheckBucketDBRP("influxdb-v2_bucket.acctest"),
+				),
+			},
+			{
+				// Replacing the bucket moves its mapping to the new bucket
+				Config: testAccCreateBucketWithDBRP("autogen"),
+				Check:  testAccCheckBucketDBRP("influxdb-v2_bucket.acctest"),
+			},
+		},
+	})
+}
+
+func testAccCreateBucketWithDBRP(rp string) string {
+	return `
+resource "influxdb-v2_bucket" "acctest" {
+    name = "acctest"
+    deletion_protection = false
+    org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+    rp = "` + rp + `"
+    retention_rules {
+        every_seconds = 0
+    }
+    dbrp {
+        database = "acctest_database"
+        retention_policy = "acctest_rp"
+        default = true
+    }
+}
+`
+}
+
+// testAccCheckBucketDBRP checks that the mapping of a bucket points to it.
+func testAccCheckBucketDBRP(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+		orgId := os.Getenv("INFLUXDB_V2_ORG_ID")
+		dbrpId := rs.Primary.Attributes["dbrp.0.id"]
+		influx := influxdb2.NewClient(
+			os.Getenv("INFLUXDB_V2_URL"),
+			os.Getenv("INFLUXDB_V2_TOKEN"),
+		)
+		dbrp, err := influx.APIClient().GetDBRPsID(context.Background(), &domain.GetDBRPsIDAllParams{
+			DbrpID: dbrpId,
+			GetDBRPsIDParams: domain.GetDBRPsIDParams{
+				OrgID: &orgId,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("Cannot read dbrp mapping %s: %v", dbrpId, err)
+		}
+		if dbrp.Content.BucketID != rs.Primary.ID {
+			return fmt.Errorf("expected dbrp mapping %s to point to bucket %s but it points to %s", dbrpId, rs.Primary.ID, dbrp.Content.BucketID)
+		}
+		return nil
+	}
+}
+
+func TestBucketDeletionProtection(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ResourceBucket().Schema, map[string]interface{}{"name": "protected"})
+	d.SetId("0000000000000001")
+	err := resourceBucketDelete(d, &meta{})
+	if err == nil || !strings.Contains(err.Error(), "deletion_protection") {
+		t.Fatalf("expected the bucket to be protected but got %v", err)
+	}
+}
+
 var lastUpdate = ""
 
 func testAccCheckUpdate(n string) resource.TestCheckFunc {
@@ -203,6 +536,7 @@ func testAccCreateBucket() string {
 resource "influxdb-v2_bucket" "acctest" {
     description = "Acceptance test bucket" 
     name = "acctest" 
+    deletion_protection = false
     org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
     retention_rules {
         every_seconds = "3640"
@@ -216,6 +550,7 @@ func testAccUpdateBucket() string {
 resource "influxdb-v2_bucket" "acctest" {
     description = "Acceptance test bucket 2" 
     name = "acctest" 
+    deletion_protection = false
     org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
     retention_rules {
         every_seconds = "3630"
@@ -229,6 +564,7 @@ func testAccNoShardGroupDurationManagementBucket() string {
 resource "influxdb-v2_bucket" "acctest_no_shard" {
     description = "Acceptance test bucket" 
     name = "acctest" 
+    deletion_protection = false
     org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
     retention_rules {
         every_seconds = "3640"
@@ -2,23 +2,35 @@ package influxdbv2
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
 func ResourceDBRPMapping() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceDBRPMappingCreate,
-		Delete: resourceDBRPMappingDelete,
-		Read:   resourceDBRPMappingRead,
-		Update: resourceDBRPMappingUpdate,
+		Create:        resourceDBRPMappingCreate,
+		Delete:        resourceDBRPMappingDelete,
+		Read:          resourceDBRPMappingRead,
+		Update:        resourceDBRPMappingUpdate,
+		CustomizeDiff: orgCustomizeDiff,
 		Schema: map[string]*schema.Schema{
+			"endpoint": endpointSchema(),
 			"org_id": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:        schema.TypeString,
+				Description: "Defaults to the organization of the provider.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"org": {
+				Type:          schema.TypeString,
+				Description:   "Name of the organization, resolved to `org_id`.",
+				Optional:      true,
+				ConflictsWith: []string{"org_id"},
 			},
 			"bucket_id": {
 				Type:     schema.TypeString,
@@ -34,45 +46,77 @@ func ResourceDBRPMapping() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			// Making a mapping the default demotes the previous default of its
+			// database
 			"default_policy": {
-				Type:     schema.TypeBool,
-				Optional: true,
-				Computed: true,
+				Type:        schema.TypeBool,
+				Description: "Whether the mapping is the default retention policy of its database. Making a mapping the default demotes the previous default of the database. Left to the server when not set.",
+				Optional:    true,
+				Computed:    true,
 			},
 		},
 	}
 }
 
 func resourceDBRPMappingCreate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	bucketId := d.Get("bucket_id").(string)
-	orgId := d.Get("org_id").(string)
+	orgId, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
 	db := d.Get("database").(string)
 	rp := d.Get("retention_policy").(string)
+	ctx := context.Background()
+
+	// Left to the server when not configured
+	var defaultPolicy *bool
+	if v := d.GetRawConfig().GetAttr("default_policy"); v.IsKnown() && !v.IsNull() {
+		value := v.True()
+		defaultPolicy = &value
+	}
+	var demoted []string
+	if defaultPolicy != nil && *defaultPolicy {
+		demoted, err = demoteDefaultDBRPs(ctx, influx, orgId, db, "")
+		if err != nil {
+			return err
+		}
+	}
 
-	dbrp, err := influx.APIClient().PostDBRP(context.Background(), &domain.PostDBRPAllParams{
+	dbrp, err := influx.APIClient().PostDBRP(ctx, &domain.PostDBRPAllParams{
 		Body: domain.PostDBRPJSONRequestBody{
 			BucketID:        bucketId,
 			Database:        db,
 			RetentionPolicy: rp,
 			OrgID:           &orgId,
+			Default:         defaultPolicy,
 		}})
 	if err != nil {
-		return fmt.Errorf("error creating dbrp mapping: %e", err)
+		return restoreDefaultDBRPs(ctx, influx, orgId, demoted, fmt.Errorf("error creating dbrp mapping: %v", err))
 	}
 	id := dbrp.Id
 
 	d.SetId(id)
+	err = d.Set("org_id", orgId)
+	if err != nil {
+		return err
+	}
 
 	return resourceDBRPMappingRead(d, m)
 }
 
 func resourceDBRPMappingDelete(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	orgId := d.Get("org_id").(string)
 	id := d.Id()
 
-	err := influx.APIClient().DeleteDBRPID(context.Background(), &domain.DeleteDBRPIDAllParams{
+	err = influx.APIClient().DeleteDBRPID(context.Background(), &domain.DeleteDBRPIDAllParams{
 		DbrpID: id,
 		DeleteDBRPIDParams: domain.DeleteDBRPIDParams{
 			OrgID: &orgId,
@@ -87,7 +131,10 @@ func resourceDBRPMappingDelete(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceDBRPMappingRead(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	orgId := d.Get("org_id").(string)
 
 	dbrp, err := influx.APIClient().GetDBRPsID(context.Background(), &domain.GetDBRPsIDAllParams{
@@ -131,23 +178,96 @@ func resourceDBRPMappingRead(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceDBRPMappingUpdate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	id := d.Id()
 	orgId := d.Get("org_id").(string)
 	rp := d.Get("retention_policy").(string)
+	ctx := context.Background()
+
+	var defaultPolicy *bool
+	var demoted []string
+	if d.HasChange("default_policy") {
+		value := d.Get("default_policy").(bool)
+		defaultPolicy = &value
+		if value {
+			demoted, err = demoteDefaultDBRPs(ctx, influx, orgId, d.Get("database").(string), id)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
-	_, err := influx.APIClient().PatchDBRPID(context.Background(), &domain.PatchDBRPIDAllParams{
+	_, err = influx.APIClient().PatchDBRPID(ctx, &domain.PatchDBRPIDAllParams{
 		PatchDBRPIDParams: domain.PatchDBRPIDParams{
 			OrgID: &orgId,
 		},
 		DbrpID: id,
 		Body: domain.PatchDBRPIDJSONRequestBody{
 			RetentionPolicy: &rp,
+			Default:         defaultPolicy,
 		},
 	})
 
 	if err != nil {
-		return fmt.Errorf("error updating authorization: %v", err)
+		return restoreDefaultDBRPs(ctx, influx, orgId, demoted, fmt.Errorf("error updating dbrp mapping: %v", err))
 	}
 	return resourceDBRPMappingRead(d, m)
 }
+
+// demoteDefaultDBRPs unsets the default of the mappings of a database other
+// than keepID, so that another mapping can become its default without a
+// conflict. Virtual mappings, made by the server from bucket names, are left
+// alone. It returns the IDs of the demoted mappings.
+func demoteDefaultDBRPs(ctx context.Context, influx influxdb2.Client, orgID, database, keepID string) ([]string, error) {
+	isDefault := true
+	result, err := influx.APIClient().GetDBRPs(ctx, &domain.GetDBRPsParams{
+		OrgID:   &orgID,
+		Db:      &database,
+		Default: &isDefault,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting the default dbrp mapping of %s: %v", database, err)
+	}
+	if result.Content == nil {
+		return nil, nil
+	}
+	var demoted []string
+	for _, dbrp := range *result.Content {
+		if dbrp.Id == keepID || (dbrp.Virtual != nil && *dbrp.Virtual) || !dbrp.Default {
+			continue
+		}
+		err = setDBRPDefault(ctx, influx, orgID, dbrp.Id, false)
+		if err != nil {
+			return nil, restoreDefaultDBRPs(ctx, influx, orgID, demoted, fmt.Errorf("error demoting dbrp mapping %s: %v", dbrp.Id, err))
+		}
+		demoted = append(demoted, dbrp.Id)
+	}
+	return demoted, nil
+}
+
+// restoreDefaultDBRPs makes the demoted mappings the default again after cause
+// prevented another one from becoming the default, and returns cause.
+func restoreDefaultDBRPs(ctx context.Context, influx influxdb2.Client, orgID string, demoted []string, cause error) error {
+	for _, id := range demoted {
+		if err := setDBRPDefault(ctx, influx, orgID, id, true); err != nil {
+			cause = errors.Join(cause, fmt.Errorf("error restoring the default dbrp mapping %s: %v", id, err))
+		}
+	}
+	return cause
+}
+
+func setDBRPDefault(ctx context.Context, influx influxdb2.Client, orgID, id string, value bool) error {
+	_, err := influx.APIClient().PatchDBRPID(ctx, &domain.PatchDBRPIDAllParams{
+		PatchDBRPIDParams: domain.PatchDBRPIDParams{
+			OrgID: &orgID,
+		},
+		DbrpID: id,
+		Body: domain.PatchDBRPIDJSONRequestBody{
+			Default: &value,
+		},
+	})
+	return err
+}
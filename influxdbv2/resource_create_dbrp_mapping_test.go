@@ -16,7 +16,7 @@ import (
 var dbrpIdOnCreate string
 
 func TestAccDBRPMapping(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
 		CheckDestroy: testAccDBRPMappingDestroyed,
@@ -86,6 +86,52 @@ resource "influxdb-v2_dbrp_mapping" "acctest" {
 `
 }
 
+func TestAccDBRPMappingDefault(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccDBRPMappingDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDBRPMappingDefault("first"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_dbrp_mapping.first", "default_policy", "true"),
+					resource.TestCheckResourceAttr("influxdb-v2_dbrp_mapping.second", "default_policy", "false"),
+				),
+			},
+			{
+				// Promoting the second mapping demotes the first one
+				Config: testAccDBRPMappingDefault("second"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_dbrp_mapping.first", "default_policy", "false"),
+					resource.TestCheckResourceAttr("influxdb-v2_dbrp_mapping.second", "default_policy", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDBRPMappingDefault(defaultMapping string) string {
+	return fmt.Sprintf(`
+resource "influxdb-v2_dbrp_mapping" "first" {
+	org_id = "%[1]s"
+	bucket_id = "%[2]s"
+	database = "legacy_default_database"
+	retention_policy = "first_rp"
+	default_policy = %[3]t
+}
+
+resource "influxdb-v2_dbrp_mapping" "second" {
+	org_id = "%[1]s"
+	bucket_id = "%[2]s"
+	database = "legacy_default_database"
+	retention_policy = "second_rp"
+	default_policy = %[4]t
+	depends_on = [influxdb-v2_dbrp_mapping.first]
+}
+`, os.Getenv("INFLUXDB_V2_ORG_ID"), os.Getenv("INFLUXDB_V2_BUCKET_ID"), defaultMapping == "first", defaultMapping == "second")
+}
+
 func testAccDBRPMappingDestroyed(s *terraform.State) error {
 	orgId := os.Getenv("INFLUXDB_V2_ORG_ID")
 	tls := &tls.Config{
@@ -0,0 +1,250 @@
+package influxdbv2
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// ResourceDeleteData deletes points from a bucket when it is created, and
+// again whenever one of its arguments or triggers changes. Destroying it
+// deletes nothing.
+func ResourceDeleteData() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceDeleteDataCreate,
+		Delete:        resourceDeleteDataDelete,
+		Read:          resourceDeleteDataRead,
+		Update:        resourceDeleteDataUpdate,
+		CustomizeDiff: customdiff.All(orgCustomizeDiff, resourceDeleteDataCustomizeDiff),
+		Schema: map[string]*schema.Schema{
+			"endpoint": endpointSchema(),
+			"org_id": {
+				Type:        schema.TypeString,
+				Description: "Defaults to the organization of the provider.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"org": {
+				Type:          schema.TypeString,
+				Description:   "Name of the organization, resolved to `org_id`.",
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"org_id"},
+			},
+			"bucket_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"start": {
+				Type:         schema.TypeString,
+				Description:  "Start of the deleted time range as an RFC3339 time.",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"stop": {
+				Type:         schema.TypeString,
+				Description:  "End of the deleted time range as an RFC3339 time.",
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"predicate": {
+				Type:         schema.TypeString,
+				Description:  "Selects the deleted series, such as `_measurement=\"cpu\" AND host=\"a\"`. Every series in the time range is deleted when empty.",
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateDeletePredicate,
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Description: "Arbitrary values that delete the data again when they change.",
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"deleted_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceDeleteDataCreate(d *schema.ResourceData, m interface{}) error {
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	orgID, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
+	bucketID := d.Get("bucket_id").(string)
+	start, _ := time.Parse(time.RFC3339, d.Get("start").(string))
+	stop, _ := time.Parse(time.RFC3339, d.Get("stop").(string))
+	predicate := d.Get("predicate").(string)
+
+	log.Printf("[INFO] deleting data from bucket %s between %s and %s matching %q", bucketID, start, stop, predicate)
+	// Deleting a large range can take longer than the request timeout
+	err = influx.DeleteAPI().DeleteWithID(withoutRequestTimeout(context.Background()), orgID, bucketID, start, stop, predicate)
+	if err != nil {
+		return fmt.Errorf("error deleting data: %v", err)
+	}
+
+	d.SetId(id.UniqueId())
+	err = d.Set("org_id", orgID)
+	if err != nil {
+		return err
+	}
+	err = d.Set("deleted_at", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// resourceDeleteDataRead has nothing to read, the deletion is only recorded in
+// the state.
+func resourceDeleteDataRead(d *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+// resourceDeleteDataUpdate only records new credentials of the endpoint, every
+// argument that selects data deletes it again instead.
+func resourceDeleteDataUpdate(d *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+func resourceDeleteDataDelete(d *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+func resourceDeleteDataCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	start, err := time.Parse(time.RFC3339, d.Get("start").(string))
+	if err != nil {
+		return nil
+	}
+	stop, err := time.Parse(time.RFC3339, d.Get("stop").(string))
+	if err != nil {
+		return nil
+	}
+	if !start.Before(stop) {
+		return fmt.Errorf("start %s must be before stop %s", d.Get("start"), d.Get("stop"))
+	}
+	return nil
+}
+
+func validateDeletePredicate(v interface{}, k string) (ws []string, es []error) {
+	if err := parseDeletePredicate(v.(string)); err != nil {
+		es = append(es, fmt.Errorf("invalid delete predicate in %s: %s", k, err))
+	}
+	return
+}
+
+// parseDeletePredicate checks a predicate of the delete API, which compares
+// tags to values with = or != and joins the comparisons with AND, such as
+// _measurement="cpu" AND host!="a". Keys are identifiers or double quoted, and
+// values are single or double quoted. OR, parentheses, regular expressions and
+// _field are rejected by the server.
+func parseDeletePredicate(predicate string) error {
+	rest := strings.TrimSpace(predicate)
+	if rest == "" {
+		return nil
+	}
+	for {
+		key, after, err := readPredicateKey(rest)
+		if err != nil {
+			return err
+		}
+		if key == "_field" {
+			return fmt.Errorf("deleting by _field is not supported, select series by _measurement and tags")
+		}
+		rest = strings.TrimLeft(after, " \t\n")
+		switch {
+		case strings.HasPrefix(rest, "!="):
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "=~"), strings.HasPrefix(rest, "!~"):
+			return fmt.Errorf("regular expressions are not supported, compare %s with = or !=", key)
+		case strings.HasPrefix(rest, "="):
+			rest = rest[1:]
+		default:
+			return fmt.Errorf("expected = or != after %s", key)
+		}
+		rest = strings.TrimLeft(rest, " \t\n")
+		rest, err = readPredicateValue(key, rest)
+		if err != nil {
+			return err
+		}
+		rest = strings.TrimLeft(rest, " \t\n")
+		if rest == "" {
+			return nil
+		}
+		word, after := rest, ""
+		if i := strings.IndexAny(rest, " \t\n"); i >= 0 {
+			word, after = rest[:i], rest[i:]
+		}
+		switch strings.ToUpper(word) {
+		case "AND":
+			rest = strings.TrimLeft(after, " \t\n")
+		case "OR":
+			return fmt.Errorf("OR is not supported, delete each selection with its own predicate")
+		default:
+			return fmt.Errorf("expected AND before %q", rest)
+		}
+	}
+}
+
+// readPredicateKey reads the tag key at the start of a predicate and returns
+// the rest.
+func readPredicateKey(s string) (string, string, error) {
+	if strings.HasPrefix(s, "(") {
+		return "", "", fmt.Errorf("parentheses are not supported")
+	}
+	if strings.HasPrefix(s, `"`) {
+		end := strings.Index(s[1:], `"`)
+		if end < 1 {
+			return "", "", fmt.Errorf("unterminated or empty key in %q", s)
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	i := 0
+	for i < len(s) && isPredicateKeyChar(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return "", "", fmt.Errorf("expected a tag key at %q", s)
+	}
+	return s[:i], s[i:], nil
+}
+
+// readPredicateValue reads the quoted value compared to key and returns the
+// rest.
+func readPredicateValue(key, s string) (string, error) {
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return "", fmt.Errorf("expected a quoted value after %s", key)
+	}
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return s[i+1:], nil
+		}
+	}
+	return "", fmt.Errorf("unterminated value of %s", key)
+}
+
+func isPredicateKeyChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,137 @@
+package influxdbv2
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+func TestAccDeleteData(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() { writeDeleteDataPoints("1") },
+				Config:    testAccDeleteData("1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("influxdb-v2_delete_data.acctest", "deleted_at"),
+					testAccCheckDeleteDataPoints(1),
+				),
+			},
+			{
+				// Changing a trigger deletes the data again
+				PreConfig: func() { writeDeleteDataPoints("2") },
+				Config:    testAccDeleteData("2"),
+				Check:     testAccCheckDeleteDataPoints(1),
+			},
+			{
+				Config:      testAccDeleteDataWithPredicate(`_measurement="acctest_delete" OR host="a"`, "2"),
+				ExpectError: regexp.MustCompile("OR is not supported"),
+			},
+		},
+	})
+}
+
+func testAccDeleteData(run string) string {
+	return testAccDeleteDataWithPredicate(`_measurement="acctest_delete" AND host="a"`, run)
+}
+
+func testAccDeleteDataWithPredicate(predicate string, run string) string {
+	return fmt.Sprintf(`
+resource "influxdb-v2_delete_data" "acctest" {
+    org_id = "%s"
+    bucket_id = "%s"
+    start = "1970-01-01T00:00:00Z"
+    stop = "2100-01-01T00:00:00Z"
+    predicate = %q
+    triggers = {
+        run = "%s"
+    }
+}
+`, os.Getenv("INFLUXDB_V2_ORG_ID"), os.Getenv("INFLUXDB_V2_BUCKET_ID"), predicate, run)
+}
+
+// writeDeleteDataPoints writes a point for the hosts a and b, of which the
+// resource deletes a.
+func writeDeleteDataPoints(run string) {
+	influx := influxdb2.NewClient(
+		os.Getenv("INFLUXDB_V2_URL"),
+		os.Getenv("INFLUXDB_V2_TOKEN"),
+	)
+	write := influx.WriteAPIBlocking(os.Getenv("INFLUXDB_V2_ORG_ID"), os.Getenv("INFLUXDB_V2_BUCKET_ID"))
+	for _, host := range []string{"a", "b"} {
+		p := influxdb2.NewPoint("acctest_delete", map[string]string{"host": host, "run": run}, map[string]interface{}{"value": 1}, time.Now())
+		if err := write.WritePoint(context.Background(), p); err != nil {
+			panic("Cannot write point")
+		}
+	}
+}
+
+// testAccCheckDeleteDataPoints counts the series left of the measurement.
+func testAccCheckDeleteDataPoints(hostsLeft int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		influx := influxdb2.NewClient(
+			os.Getenv("INFLUXDB_V2_URL"),
+			os.Getenv("INFLUXDB_V2_TOKEN"),
+		)
+		query := fmt.Sprintf(`from(bucketID: %q)
+  |> range(start: 0)
+  |> filter(fn: (r) => r._measurement == "acctest_delete")
+  |> keep(columns: ["host"])
+  |> distinct(column: "host")`, os.Getenv("INFLUXDB_V2_BUCKET_ID"))
+		result, err := influx.QueryAPI(os.Getenv("INFLUXDB_V2_ORG_ID")).Query(context.Background(), query)
+		if err != nil {
+			return fmt.Errorf("Cannot query points: %v", err)
+		}
+		hosts := map[interface{}]bool{}
+		for result.Next() {
+			if result.Record().Value() == "a" {
+				return fmt.Errorf("expected the points of host a to be deleted")
+			}
+			hosts[result.Record().Value()] = true
+		}
+		if len(hosts) != hostsLeft {
+			return fmt.Errorf("expected %d hosts left but got %v", hostsLeft, hosts)
+		}
+		return result.Err()
+	}
+}
+
+func TestParseDeletePredicate(t *testing.T) {
+	for _, predicate := range []string{
+		``,
+		`_measurement="cpu"`,
+		`_measurement="cpu" AND host="a"`,
+		`_measurement = 'cpu' and "host name"!="a b"`,
+		"_measurement=\"cpu\"\nAND region=\"eu-west\"",
+		`host="say \"hi\""`,
+	} {
+		if err := parseDeletePredicate(predicate); err != nil {
+			t.Errorf("err parsing %q: %s", predicate, err)
+		}
+	}
+	for predicate, reason := range map[string]string{
+		`_measurement="cpu" OR host="a"`: "OR is not supported",
+		`(_measurement="cpu")`:           "parentheses",
+		`host=~/a.*/`:                    "regular expressions",
+		`_field="usage"`:                 "_field",
+		`host=a`:                         "quoted value",
+		`host="a`:                        "unterminated",
+		`host="a" AND`:                   "tag key",
+		`host="a" host="b"`:              "expected AND",
+		`host`:                           "expected = or !=",
+	} {
+		err := parseDeletePredicate(predicate)
+		if err == nil || !regexp.MustCompile(reason).MatchString(err.Error()) {
+			t.Errorf("expected %q to be rejected for %q but got %v", predicate, reason, err)
+		}
+	}
+}
@@ -2,17 +2,45 @@ package influxdbv2
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func ResourceLegacyAuthorization() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceLegacyAuthorizationCreate,
-		Delete: resourceLegacyAuthorizationDelete,
-		Read:   resourceLegacyAuthorizationRead,
-		Update: resourceLegacyAuthorizationUpdate,
+		Create:        resourceLegacyAuthorizationCreate,
+		Delete:        resourceLegacyAuthorizationDelete,
+		Read:          resourceLegacyAuthorizationRead,
+		Update:        resourceLegacyAuthorizationUpdate,
+		CustomizeDiff: orgCustomizeDiff,
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceLegacyAuthorizationV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceLegacyAuthorizationStateUpgradeV0,
+			},
+		},
+		ValidateRawResourceConfigFuncs: []schema.ValidateRawResourceConfigFunc{
+			validation.PreferWriteOnlyAttribute(cty.GetAttrPath("password"), cty.GetAttrPath("password_wo")),
+		},
+		Schema: legacyAuthorizationSchema(),
+	}
+}
+
+// resourceLegacyAuthorizationV0 is the schema used before the password was
+// hashed in state and could be provided as a write-only attribute. It is a
+// copy so that later changes to the resource don't change how old states are
+// read.
+func resourceLegacyAuthorizationV0() *schema.Resource {
+	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"org_id": {
 				Type:     schema.TypeString,
@@ -91,12 +119,124 @@ func ResourceLegacyAuthorization() *schema.Resource {
 	}
 }
 
+func legacyAuthorizationSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"endpoint": endpointSchema(),
+		"org_id": {
+			Type:        schema.TypeString,
+			Description: "Defaults to the organization of the provider.",
+			Optional:    true,
+			Computed:    true,
+			ForceNew:    true,
+		},
+		"org": {
+			Type:          schema.TypeString,
+			Description:   "Name of the organization, resolved to `org_id`.",
+			Optional:      true,
+			ConflictsWith: []string{"org_id"},
+		},
+		"description": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"status": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "active",
+		},
+		"name": {
+			Type:     schema.TypeString,
+			ForceNew: true,
+			Required: true,
+		},
+		"password": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Sensitive:    true,
+			ExactlyOneOf: []string{"password", "password_wo"},
+			// Only a hash of the password is kept in state
+			StateFunc: func(v interface{}) string {
+				return hashLegacyAuthorizationPassword(v.(string))
+			},
+		},
+		"password_wo": {
+			Type:      schema.TypeString,
+			Optional:  true,
+			Sensitive: true,
+			WriteOnly: true,
+		},
+		"password_version": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			RequiredWith: []string{"password_wo"},
+		},
+		"permissions": {
+			Type:     schema.TypeSet,
+			Required: true,
+			ForceNew: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"action": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"resource": {
+						Type:     schema.TypeSet,
+						Required: true,
+						Elem: &schema.Resource{
+							Schema: map[string]*schema.Schema{
+								"id": {
+									Type:     schema.TypeString,
+									Required: true,
+								},
+								"org": {
+									Type:     schema.TypeString,
+									Optional: true,
+								},
+								"org_id": {
+									Type:     schema.TypeString,
+									Required: true,
+								},
+								"type": {
+									Type:     schema.TypeString,
+									Required: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"user_id": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+			Computed: true,
+		},
+		"user_org_id": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+			Computed: true,
+		},
+	}
+}
+
 func resourceLegacyAuthorizationCreate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).legacyAuthorizationsClient
+	influx, err := m.(*meta).legacyClient(d)
+	if err != nil {
+		return err
+	}
 	description := d.Get("description").(string)
-	orgId := d.Get("org_id").(string)
+	orgId, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
 	username := d.Get("name").(string)
-	password := d.Get("password").(string)
+	password, err := getLegacyAuthorizationPassword(d)
+	if err != nil {
+		return err
+	}
 	status := LegacyAuthorizationPostRequestStatus(d.Get("status").(string))
 	permissions := getLegacyPermissions(d.Get("permissions"))
 	ctx := context.Background()
@@ -109,9 +249,11 @@ func resourceLegacyAuthorizationCreate(d *schema.ResourceData, m interface{}) er
 		OrgID:       &orgId,
 		Status:      &status,
 	})
-	if err != nil || authorization.StatusCode() != 201 {
-		resourceLegacyAuthorizationDelete(d, m)
-		return fmt.Errorf("error creating legacy authorization: %e", err)
+	if err != nil {
+		return fmt.Errorf("error creating legacy authorization: %v", err)
+	}
+	if authorization.StatusCode() != 201 {
+		return legacyResponseError("creating legacy authorization", authorization.Status(), authorization.Body)
 	}
 	userId := *authorization.JSON201.Id
 
@@ -119,17 +261,23 @@ func resourceLegacyAuthorizationCreate(d *schema.ResourceData, m interface{}) er
 	pass, err := influx.PostLegacyAuthorizationsIDPasswordWithResponse(ctx, userId, &PostLegacyAuthorizationsIDPasswordParams{}, PostLegacyAuthorizationsIDPasswordJSONRequestBody{
 		Password: password,
 	})
-	// If password fails, delete the authorization
-	if err != nil || pass.StatusCode() != 204 {
-		resourceLegacyAuthorizationDelete(d, m)
-		return fmt.Errorf("error creating legacy authorization password: %e", err)
+	if err != nil {
+		err = fmt.Errorf("error creating legacy authorization password: %v", err)
+	} else if pass.StatusCode() != 204 {
+		err = legacyResponseError("creating legacy authorization password", pass.Status(), pass.Body)
 	}
-
-	d.SetId(userId)
-	err = d.Set("name", *authorization.JSON201.Token)
+	// If password fails, delete the authorization so that it isn't left on the
+	// server without a password and without being tracked in state
 	if err != nil {
+		rollbackErr := deleteLegacyAuthorizationWithID(ctx, influx, userId)
+		if rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("legacy authorization %s could not be rolled back: %v", userId, rollbackErr))
+		}
 		return err
 	}
+
+	d.SetId(userId)
+	err = d.Set("name", *authorization.JSON201.Token)
 	if err != nil {
 		return err
 	}
@@ -137,27 +285,29 @@ func resourceLegacyAuthorizationCreate(d *schema.ResourceData, m interface{}) er
 }
 
 func resourceLegacyAuthorizationDelete(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).legacyAuthorizationsClient
-	id := d.Id()
-	_, err := influx.DeleteLegacyAuthorizationsIDWithResponse(context.Background(), id, &DeleteLegacyAuthorizationsIDParams{})
+	influx, err := m.(*meta).legacyClient(d)
 	if err != nil {
-		return fmt.Errorf("error deleting authorization: %v", err)
+		return err
 	}
-	return nil
+	return deleteLegacyAuthorizationWithID(context.Background(), influx, d.Id())
 }
 
 func resourceLegacyAuthorizationRead(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).legacyAuthorizationsClient
-	password := d.Get("password").(string)
+	influx, err := m.(*meta).legacyClient(d)
+	if err != nil {
+		return err
+	}
 
 	authorization, err := influx.GetLegacyAuthorizationsIDWithResponse(context.Background(), d.Id(), &GetLegacyAuthorizationsIDParams{})
+	if err != nil {
+		return fmt.Errorf("error getting legacy authorization: %v", err)
+	}
 	if authorization.StatusCode() == 404 {
 		d.SetId("")
 		return nil
 	}
-
-	if err != nil || authorization.StatusCode() != 200 {
-		return fmt.Errorf("error getting authorization: %v", err)
+	if authorization.StatusCode() != 200 {
+		return legacyResponseError("getting legacy authorization", authorization.Status(), authorization.Body)
 	}
 
 	err = d.Set("status", authorization.JSON200.Status)
@@ -184,18 +334,16 @@ func resourceLegacyAuthorizationRead(d *schema.ResourceData, m interface{}) erro
 	if err != nil {
 		return err
 	}
-	err = d.Set("password", password)
-	if err != nil {
-		return err
-	}
 	return nil
 }
 
 func resourceLegacyAuthorizationUpdate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).legacyAuthorizationsClient
+	influx, err := m.(*meta).legacyClient(d)
+	if err != nil {
+		return err
+	}
 	id := d.Id()
 	description := d.Get("description").(string)
-	password := d.Get("password").(string)
 	status := AuthorizationUpdateRequestStatus(d.Get("status").(string))
 	ctx := context.Background()
 
@@ -203,21 +351,92 @@ func resourceLegacyAuthorizationUpdate(d *schema.ResourceData, m interface{}) er
 		Description: &description,
 		Status:      &status,
 	})
-	if err != nil || authorization.StatusCode() != 200 {
-		return fmt.Errorf("error updating legacy authorization: %e", err)
+	if err != nil {
+		return fmt.Errorf("error updating legacy authorization: %v", err)
+	}
+	if authorization.StatusCode() != 200 {
+		return legacyResponseError("updating legacy authorization", authorization.Status(), authorization.Body)
 	}
 
-	// Update the password on the authorization
-	pass, err := influx.PostLegacyAuthorizationsIDPasswordWithResponse(ctx, id, &PostLegacyAuthorizationsIDPasswordParams{}, PostLegacyAuthorizationsIDPasswordJSONRequestBody{
-		Password: password,
-	})
-	if err != nil || pass.StatusCode() != 204 {
-		return fmt.Errorf("error updating legacy authorization password: %e", err)
+	// Update the password on the authorization, only when it has changed since
+	// the write-only password is not available to compare against
+	if d.HasChanges("password", "password_wo", "password_version") {
+		password, err := getLegacyAuthorizationPassword(d)
+		if err != nil {
+			return err
+		}
+		pass, err := influx.PostLegacyAuthorizationsIDPasswordWithResponse(ctx, id, &PostLegacyAuthorizationsIDPasswordParams{}, PostLegacyAuthorizationsIDPasswordJSONRequestBody{
+			Password: password,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating legacy authorization password: %v", err)
+		}
+		if pass.StatusCode() != 204 {
+			return legacyResponseError("updating legacy authorization password", pass.Status(), pass.Body)
+		}
 	}
 
 	return resourceLegacyAuthorizationRead(d, m)
 }
 
+func deleteLegacyAuthorizationWithID(ctx context.Context, influx *ClientWithResponses, id string) error {
+	result, err := influx.DeleteLegacyAuthorizationsIDWithResponse(ctx, id, &DeleteLegacyAuthorizationsIDParams{})
+	if err != nil {
+		return fmt.Errorf("error deleting legacy authorization: %v", err)
+	}
+	// Already gone is as good as deleted
+	if result.StatusCode() != 204 && result.StatusCode() != 404 {
+		return legacyResponseError("deleting legacy authorization", result.Status(), result.Body)
+	}
+	return nil
+}
+
+// legacyResponseError describes an unexpected response from the legacy API
+// using its status and, when the body can be decoded, the server's message.
+func legacyResponseError(action string, status string, body []byte) error {
+	var serverError Error
+	if json.Unmarshal(body, &serverError) == nil && serverError.Message != nil {
+		return fmt.Errorf("error %s: %s: %s", action, status, *serverError.Message)
+	}
+	return fmt.Errorf("error %s: %s", action, status)
+}
+
+func resourceLegacyAuthorizationStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, m interface{}) (map[string]interface{}, error) {
+	if password, ok := rawState["password"].(string); ok && password != "" {
+		rawState["password"] = hashLegacyAuthorizationPassword(password)
+	}
+	return rawState, nil
+}
+
+// getLegacyAuthorizationPassword returns the plaintext password from config,
+// preferring the write-only attribute when it has been provided.
+func getLegacyAuthorizationPassword(d *schema.ResourceData) (string, error) {
+	wo, diags := d.GetRawConfigAt(cty.GetAttrPath("password_wo"))
+	if diags.HasError() {
+		return "", fmt.Errorf("error reading password_wo from config")
+	}
+	if wo.Type().Equals(cty.String) && wo.IsKnown() && !wo.IsNull() {
+		return wo.AsString(), nil
+	}
+	return d.Get("password").(string), nil
+}
+
+// legacyAuthorizationPasswordSalt is prepended to passwords before hashing, so
+// that their hashes in state can't be looked up in tables of common hashes.
+const legacyAuthorizationPasswordSalt = "terraform-provider-influxdb-v2/legacy_authorization/password:"
+
+// hashLegacyAuthorizationPassword hashes a password for state. A StateFunc
+// only sees the value, so the salt can't be unique to the resource: a weak
+// password can still be guessed by hashing candidates, which password_wo
+// avoids by keeping nothing in state.
+func hashLegacyAuthorizationPassword(password string) string {
+	if password == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(legacyAuthorizationPasswordSalt + password))
+	return hex.EncodeToString(hash[:])
+}
+
 func getLegacyPermissions(input interface{}) []Permission {
 	result := []Permission{}
 	permissionsSet := input.(*schema.Set).List()
@@ -2,19 +2,25 @@ package influxdbv2
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"testing"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 var legacyAuthIdOnCreate string
 
 func TestAccLegacyAuthorization(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
 		CheckDestroy: testAccLegacyAuthorizationDestroyed,
@@ -34,6 +40,8 @@ func TestAccLegacyAuthorization(t *testing.T) {
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "user_org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
 					resource.TestCheckResourceAttrSet("influxdb-v2_legacy_authorization.acctest", "name"),
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
+					// Only a hash of the password should be kept in state
+					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "password", hashLegacyAuthorizationPassword("secure password")),
 					// permissions is a complex array... we'll just check it has the correct length
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "permissions.#", "2"),
 				),
@@ -44,6 +52,7 @@ func TestAccLegacyAuthorization(t *testing.T) {
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "description", "Acceptance test legacy token 2"),
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "status", "active"),
+					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "password", hashLegacyAuthorizationPassword("another secure password")),
 					resource.TestCheckResourceAttrSet("influxdb-v2_legacy_authorization.acctest", "user_id"),
 					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "user_org_id", os.Getenv("INFLUXDB_V2_ORG_ID")),
 					resource.TestCheckResourceAttrSet("influxdb-v2_legacy_authorization.acctest", "name"),
@@ -80,6 +89,40 @@ func TestAccLegacyAuthorization(t *testing.T) {
 	})
 }
 
+func TestAccLegacyAuthorizationRollback(t *testing.T) {
+	testAccRun(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		// The authorization must not be left behind when its password is rejected
+		CheckDestroy: testAccLegacyAuthorizationDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccCreateLegacyAuthorizationWithPassword("short"),
+				ExpectError: regexp.MustCompile(`error creating legacy authorization password: 400 Bad Request: .+`),
+			},
+		},
+	})
+}
+
+func testAccCreateLegacyAuthorizationWithPassword(password string) string {
+	return `
+resource "influxdb-v2_legacy_authorization" "acctest" {
+	org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+	description = "Acceptance test legacy token"
+	name = "a user name"
+	password = "` + password + `"
+	permissions {
+		action = "read"
+		resource {
+			id = "` + os.Getenv("INFLUXDB_V2_BUCKET_ID") + `"
+			org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
+			type = "buckets"
+		}
+	}
+}
+`
+}
+
 func testAccCreateLegacyAuthorization() string {
 	return `
 resource "influxdb-v2_legacy_authorization" "acctest" {
@@ -185,3 +228,177 @@ func deleteLegacyAuthorization(id string) {
 		panic("Cannot delete legacy auth")
 	}
 }
+
+func TestLegacyAuthorizationStateUpgradeV0(t *testing.T) {
+	state := map[string]interface{}{
+		"org_id":   "org",
+		"name":     "a user name",
+		"password": "secure password",
+	}
+	upgraded, err := resourceLegacyAuthorizationStateUpgradeV0(context.Background(), state, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if upgraded["password"] != hashLegacyAuthorizationPassword("secure password") {
+		t.Fatalf("expected the password to be hashed but got %q", upgraded["password"])
+	}
+	// The hash is salted, so it isn't the plain SHA-256 of the password
+	unsalted := sha256.Sum256([]byte("secure password"))
+	if upgraded["password"] == hex.EncodeToString(unsalted[:]) {
+		t.Fatal("expected the password hash to be salted")
+	}
+
+	// The version 0 schema has none of the attributes added since
+	for _, name := range []string{"org", "endpoint", "password_wo", "password_version"} {
+		if _, ok := resourceLegacyAuthorizationV0().Schema[name]; ok {
+			t.Errorf("expected %s not to be in the version 0 schema", name)
+		}
+	}
+}
+
+func TestAccLegacyAuthorizationPasswordUpdates(t *testing.T) {
+	testFakeInfluxDB(t, func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+		authorization := func(description string, password string) string {
+			return config(`
+resource "influxdb-v2_legacy_authorization" "acctest" {
+	description = "` + description + `"
+	name = "a user name"
+	` + password + `
+	permissions {
+		action = "read"
+		resource {
+			id = data.influxdb-v2_bucket.testbucket.id
+			org_id = data.influxdb-v2_bucket.testbucket.org_id
+			type = "buckets"
+		}
+	}
+}
+data "influxdb-v2_bucket" "testbucket" {
+	name = "testbucket"
+}
+`)
+		}
+		return []resource.TestStep{
+			{
+				Config: authorization("first", `password = "secure password"`),
+				Check:  f.checkLegacyPasswordPosts(1),
+			},
+			{
+				// The password is only set again when it changes
+				Config: authorization("second", `password = "secure password"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("influxdb-v2_legacy_authorization.acctest", "description", "second"),
+					f.checkLegacyPasswordPosts(1),
+				),
+			},
+			{
+				Config: authorization("second", `password = "another secure password"`),
+				Check:  f.checkLegacyPasswordPosts(2),
+			},
+		}
+	})
+}
+
+// checkLegacyPasswordPosts checks how many times a legacy password was set.
+func (f *fakeInfluxDB) checkLegacyPasswordPosts(expected int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if f.legacyPasswordPosts != expected {
+			return fmt.Errorf("expected the password to be set %d times but it was %d", expected, f.legacyPasswordPosts)
+		}
+		return nil
+	}
+}
+
+// Terraform only sends write-only attributes from version 1.11, so the
+// resource is applied directly with the configuration Terraform would send.
+func TestLegacyAuthorizationWriteOnlyPassword(t *testing.T) {
+	f := newFakeInfluxDB()
+	defer f.Close()
+	onboarding, err := f.setup()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	m, diags := providerConfigure(context.Background(), schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"url":    f.URL,
+		"token":  *onboarding.Auth.Token,
+		"org_id": *onboarding.Org.Id,
+	}))
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+
+	r := ResourceLegacyAuthorization()
+	state := &terraform.InstanceState{}
+	apply := func(description, password, version string) {
+		t.Helper()
+		raw := map[string]interface{}{
+			"description":      description,
+			"name":             "a user name",
+			"password_version": version,
+			"permissions": []interface{}{map[string]interface{}{
+				"action": "read",
+				"resource": []interface{}{map[string]interface{}{
+					"id":     *onboarding.Bucket.Id,
+					"org_id": *onboarding.Org.Id,
+					"type":   "buckets",
+				}},
+			}},
+		}
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(raw), m)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		diff.RawConfig = cty.ObjectVal(map[string]cty.Value{"password_wo": cty.StringVal(password)})
+		var diags diag.Diagnostics
+		state, diags = r.Apply(context.Background(), state, diff, m)
+		if diags.HasError() {
+			t.Fatalf("err: %v", diags)
+		}
+	}
+	password := func() string {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		return f.legacyPasswords[state.ID]
+	}
+
+	apply("first", "write-only password", "1")
+	if password() != "write-only password" || f.legacyPasswordPosts != 1 {
+		t.Fatalf("expected the write-only password to be set once but got %q set %d times", password(), f.legacyPasswordPosts)
+	}
+	if state.Attributes["password"] != "" {
+		t.Fatal("expected no password in state")
+	}
+
+	// Without a new version the password isn't set again
+	apply("second", "write-only password", "1")
+	if state.Attributes["description"] != "second" || f.legacyPasswordPosts != 1 {
+		t.Fatalf("expected only the description to be updated but the password was set %d times", f.legacyPasswordPosts)
+	}
+
+	apply("second", "another write-only password", "2")
+	if password() != "another write-only password" || f.legacyPasswordPosts != 2 {
+		t.Fatalf("expected the new password to be set but got %q set %d times", password(), f.legacyPasswordPosts)
+	}
+}
+
+func TestGetLegacyAuthorizationPassword(t *testing.T) {
+	r := ResourceLegacyAuthorization()
+	state := &terraform.InstanceState{
+		ID:         "1",
+		Attributes: map[string]string{"password": "secure password"},
+		RawConfig:  cty.ObjectVal(map[string]cty.Value{"password_wo": cty.NullVal(cty.String)}),
+	}
+	password, err := getLegacyAuthorizationPassword(r.Data(state))
+	if err != nil || password != "secure password" {
+		t.Fatalf("expected the password but got %q, %v", password, err)
+	}
+
+	// The write-only password is preferred
+	state.RawConfig = cty.ObjectVal(map[string]cty.Value{"password_wo": cty.StringVal("write-only password")})
+	password, err = getLegacyAuthorizationPassword(r.Data(state))
+	if err != nil || password != "write-only password" {
+		t.Fatalf("expected the write-only password but got %q, %v", password, err)
+	}
+}
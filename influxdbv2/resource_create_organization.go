@@ -3,6 +3,8 @@ package influxdbv2
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
@@ -14,7 +16,8 @@ func ResourceOrganization() *schema.Resource {
 		Delete: resourceOrganizationDelete,
 		Read:   resourceOrganizationRead,
 		Update: resourceOrganizationUpdate,
-		Schema: map[string]*schema.Schema{
+		Schema: mergeSchemas(map[string]*schema.Schema{
+			"endpoint": endpointSchema(),
 			"description": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -23,24 +26,22 @@ func ResourceOrganization() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
-			"id": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"created_at": {
-				Type:     schema.TypeString,
-				Computed: true,
+			// Deleting an organization deletes the data of all of its buckets
+			"prevent_destroy_if_nonempty": {
+				Type:        schema.TypeBool,
+				Description: "Refuses to delete the organization while it has buckets other than the system ones.",
+				Optional:    true,
+				Default:     false,
 			},
-			"updated_at": {
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-		},
+		}, createUpdatedSchema("Organization")),
 	}
 }
 
 func resourceOrganizationCreate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	desc := d.Get("description").(string)
 	newOrg := &domain.Organization{
 		Name:        d.Get("name").(string),
@@ -56,8 +57,26 @@ func resourceOrganizationCreate(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceOrganizationDelete(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
-	err := influx.OrganizationsAPI().
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	if d.Get("prevent_destroy_if_nonempty").(bool) {
+		buckets, err := influx.BucketsAPI().FindBucketsByOrgID(context.Background(), d.Id())
+		if err != nil {
+			return fmt.Errorf("error getting buckets of organization: %v", err)
+		}
+		var names []string
+		for _, bucket := range *buckets {
+			if bucket.Type == nil || *bucket.Type != domain.BucketTypeSystem {
+				names = append(names, bucket.Name)
+			}
+		}
+		if len(names) > 0 {
+			return fmt.Errorf("organization %s still has buckets %s, delete them or set prevent_destroy_if_nonempty to false and apply before deleting it", d.Get("name").(string), strings.Join(names, ", "))
+		}
+	}
+	err = influx.OrganizationsAPI().
 		DeleteOrganizationWithID(context.Background(), d.Id())
 	if err != nil {
 		return fmt.Errorf("error deleting organization: %v", err)
@@ -67,12 +86,15 @@ func resourceOrganizationDelete(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceOrganizationRead(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	result, err := influx.OrganizationsAPI().
 		FindOrganizationByID(context.Background(), d.Id())
 	if err != nil {
-		notFoundError := "not found: organization not found"
-		if err.Error() == notFoundError {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] organization %s was deleted outside of Terraform", d.Id())
 			d.SetId("")
 			return nil
 		}
@@ -86,15 +108,19 @@ func resourceOrganizationRead(d *schema.ResourceData, m interface{}) error {
 	if err != nil {
 		return err
 	}
-	err = d.Set("id", result.Id)
+	err = d.Set("created_at", result.CreatedAt.String())
 	if err != nil {
 		return err
 	}
-	err = d.Set("created_at", result.CreatedAt.String())
+	err = d.Set("updated_at", result.UpdatedAt.String())
 	if err != nil {
 		return err
 	}
-	err = d.Set("updated_at", result.UpdatedAt.String())
+	err = d.Set("created_timestamp", result.CreatedAt.UnixNano()/1000000)
+	if err != nil {
+		return err
+	}
+	err = d.Set("updated_timestamp", result.UpdatedAt.UnixNano()/1000000)
 	if err != nil {
 		return err
 	}
@@ -102,7 +128,10 @@ func resourceOrganizationRead(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceOrganizationUpdate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	id := d.Id()
 	desc := d.Get("description").(string)
 
@@ -111,7 +140,7 @@ func resourceOrganizationUpdate(d *schema.ResourceData, m interface{}) error {
 		Description: &desc,
 		Name:        d.Get("name").(string),
 	}
-	_, err := influx.OrganizationsAPI().
+	_, err = influx.OrganizationsAPI().
 		UpdateOrganization(context.Background(), updateOrg)
 	if err != nil {
 		return fmt.Errorf("error updating organization: %v", err)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -14,7 +15,7 @@ import (
 var organizationIdOnCreate string
 
 func TestAccCreateOrganization(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
 		CheckDestroy: testAccOrganizationDestroyed,
@@ -96,6 +97,57 @@ func TestAccCreateOrganization(t *testing.T) {
 	})
 }
 
+func TestAccOrganizationPreventDestroyIfNonempty(t *testing.T) {
+	var orgId string
+	testAccRun(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccOrganizationDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "influxdb-v2_organization" "acctest" {
+    name = "acctest_nonempty"
+    prevent_destroy_if_nonempty = true
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					func(s *terraform.State) error {
+						orgId = extractIdForResource(s, "influxdb-v2_organization.acctest")
+						return nil
+					},
+					resource.TestCheckResourceAttrSet("influxdb-v2_organization.acctest", "created_timestamp"),
+					resource.TestCheckResourceAttrSet("influxdb-v2_organization.acctest", "updated_timestamp"),
+				),
+			},
+			{
+				PreConfig: func() {
+					createBucketInOrganization(orgId, "acctest_nonempty")
+				},
+				Config:      "locals {}",
+				ExpectError: regexp.MustCompile("organization acctest_nonempty still has buckets acctest_nonempty"),
+			},
+			{
+				PreConfig: func() {
+					deleteBucket("acctest_nonempty")
+				},
+				Config: "locals {}",
+			},
+		},
+	})
+}
+
+func createBucketInOrganization(orgId string, name string) {
+	influx := influxdb2.NewClient(
+		os.Getenv("INFLUXDB_V2_URL"),
+		os.Getenv("INFLUXDB_V2_TOKEN"),
+	)
+	_, err := influx.BucketsAPI().CreateBucketWithNameWithID(context.Background(), orgId, name)
+	if err != nil {
+		panic("Cannot create bucket")
+	}
+}
+
 var lastOrgUpdate = ""
 
 func testAccCheckOrganizationUpdate(n string) resource.TestCheckFunc {
@@ -3,37 +3,60 @@ package influxdbv2
 import (
 	"context"
 	"fmt"
+	"log"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/influxdata/influxdb-client-go/v2/domain"
 )
 
+// Types of scraper targets known to the server
+var scraperTypes = []string{string(domain.ScraperTargetRequestTypePrometheus)}
+
 func ResourceScraper() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceScraperCreate,
-		Delete: resourceScraperDelete,
-		Read:   resourceScraperRead,
-		Update: resourceScraperUpdate,
+		Create:        resourceScraperCreate,
+		Delete:        resourceScraperDelete,
+		Read:          resourceScraperRead,
+		Update:        resourceScraperUpdate,
+		CustomizeDiff: customdiff.All(orgCustomizeDiff, resourceScraperCustomizeDiff),
 		Schema: map[string]*schema.Schema{
+			"endpoint": endpointSchema(),
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			// Scrapers cannot be moved to another organization
 			"org_id": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:        schema.TypeString,
+				Description: "Defaults to the organization of the provider. Changing it replaces the scraper, as scrapers cannot be moved to another organization.",
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+			},
+			"org": {
+				Type:          schema.TypeString,
+				Description:   "Name of the organization, resolved to `org_id`.",
+				Optional:      true,
+				ConflictsWith: []string{"org_id"},
 			},
 			"bucket_id": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
 			"url": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Description:  "Absolute `http` or `https` URL of the metrics endpoint.",
+				Required:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
 			},
 			"type": {
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Description:  "Type of the scraped metrics. The server only supports `prometheus`, which is the default.",
+				Optional:     true,
+				Default:      string(domain.ScraperTargetRequestTypePrometheus),
+				ValidateFunc: validation.StringInSlice(scraperTypes, false),
 			},
 			"allow_insecure": {
 				Type:     schema.TypeBool,
@@ -43,13 +66,46 @@ func ResourceScraper() *schema.Resource {
 	}
 }
 
+var errScrapersOnCloud = fmt.Errorf("scrapers are not supported by InfluxDB Cloud, collect Prometheus metrics with Telegraf instead")
+
+// resourceScraperCustomizeDiff refuses to plan scrapers on InfluxDB Cloud.
+// A server that can't be reached yet, such as one created by the same apply,
+// is left to the check made when the scraper is created.
+func resourceScraperCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() != "" || !d.GetRawConfig().GetAttr("endpoint").IsWhollyKnown() {
+		return nil
+	}
+	server, err := m.(*meta).probeServer(ctx, d)
+	if err != nil {
+		log.Printf("[DEBUG] not checking whether the server supports scrapers: %s", err)
+		return nil
+	}
+	if server.cloud {
+		return errScrapersOnCloud
+	}
+	return nil
+}
+
 func resourceScraperCreate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
-	orgid := d.Get("org_id").(string)
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	server, err := m.(*meta).getServer(d)
+	if err != nil {
+		return err
+	}
+	if server.cloud {
+		return errScrapersOnCloud
+	}
+	orgid, err := getOrgID(d, m)
+	if err != nil {
+		return err
+	}
 	bucketid := d.Get("bucket_id").(string)
 	name := d.Get("name").(string)
 	insecure := d.Get("allow_insecure").(bool)
-	targettype := domain.ScraperTargetRequestType("prometheus")
+	targettype := domain.ScraperTargetRequestType(d.Get("type").(string))
 	url := d.Get("url").(string)
 
 	newScraper := &domain.PostScrapersAllParams{
@@ -71,8 +127,11 @@ func resourceScraperCreate(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceScraperDelete(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
-	err := influx.APIClient().DeleteScrapersID(context.Background(), &domain.DeleteScrapersIDAllParams{
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
+	err = influx.APIClient().DeleteScrapersID(context.Background(), &domain.DeleteScrapersIDAllParams{
 		ScraperTargetID: d.Id(),
 	})
 	if err != nil {
@@ -83,7 +142,10 @@ func resourceScraperDelete(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceScraperRead(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	result, err := influx.APIClient().GetScrapersID(context.Background(), &domain.GetScrapersIDAllParams{
 		ScraperTargetID: d.Id(),
 	})
@@ -132,12 +194,15 @@ func resourceScraperRead(d *schema.ResourceData, m interface{}) error {
 }
 
 func resourceScraperUpdate(d *schema.ResourceData, m interface{}) error {
-	influx := m.(meta).influxsdk
+	influx, err := m.(*meta).client(d)
+	if err != nil {
+		return err
+	}
 	orgid := d.Get("org_id").(string)
 	bucketid := d.Get("bucket_id").(string)
 	name := d.Get("name").(string)
 	insecure := d.Get("allow_insecure").(bool)
-	targettype := domain.ScraperTargetRequestType("prometheus")
+	targettype := domain.ScraperTargetRequestType(d.Get("type").(string))
 	url := d.Get("url").(string)
 
 	updateScraper := &domain.PatchScrapersIDAllParams{
@@ -151,7 +216,6 @@ func resourceScraperUpdate(d *schema.ResourceData, m interface{}) error {
 			Url:           &url,
 		},
 	}
-	var err error
 	_, err = influx.APIClient().PatchScrapersID(context.Background(), updateScraper)
 
 	if err != nil {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -15,11 +17,15 @@ import (
 var scraperIdOnCreate string
 
 func TestAccCreateScraper(t *testing.T) {
-	resource.Test(t, resource.TestCase{
+	testAccRun(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
 		CheckDestroy: testAccScraperDestroyed,
 		Steps: []resource.TestStep{
+			{
+				Config:      strings.Replace(testAccUpdateScraper(), "http://localhost:8086/metrics2", "ftp://localhost:8086/metrics2", 1),
+				ExpectError: regexp.MustCompile("to have a url with schema"),
+			},
 			{
 				Config: testAccCreateScraper(),
 				Check: resource.ComposeTestCheckFunc(
@@ -102,11 +108,31 @@ func TestAccCreateScraper(t *testing.T) {
 	})
 }
 
+func TestAccScraperOnCloud(t *testing.T) {
+	testFakeInfluxDB(t, func(f *fakeInfluxDB, config func(string) string) []resource.TestStep {
+		f.cloud = true
+		return []resource.TestStep{{
+			// Refused when planning, not only when creating
+			Config: config(`
+resource "influxdb-v2_scraper" "acctest" {
+	name = "acctest"
+	bucket_id = "0000000000000001"
+	url = "http://localhost:8086/metrics"
+	allow_insecure = false
+}
+`),
+			PlanOnly:    true,
+			ExpectError: regexp.MustCompile("scrapers are not supported by InfluxDB Cloud"),
+		}}
+	})
+}
+
 func testAccCreateScraper() string {
 	return `
 resource "influxdb-v2_bucket" "acctest" {
 	description = "Acceptance test bucket" 
 	name = "acctest" 
+	deletion_protection = false
 	org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
 	retention_rules {
 		every_seconds = "3640"
@@ -127,6 +153,7 @@ func testAccUpdateScraper() string {
 resource "influxdb-v2_bucket" "acctest" {
 	description = "Acceptance test bucket" 
 	name = "acctest" 
+	deletion_protection = false
 	org_id = "` + os.Getenv("INFLUXDB_V2_ORG_ID") + `"
 	retention_rules {
 		every_seconds = "3640"
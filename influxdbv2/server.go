@@ -0,0 +1,91 @@
+package influxdbv2
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+// Oldest OSS version that accepts a shard group duration on retention rules
+const shardGroupDurationVersion = "2.0.4"
+
+// serverInfo describes the server the provider is connected to, so that
+// resources can adapt to what it supports.
+type serverInfo struct {
+	cloud bool
+	// Version reported by /health without its leading v, empty when unknown
+	version string
+}
+
+// detectServer tells InfluxDB Cloud apart from OSS and finds the version of
+// OSS servers. A server whose /health cannot be read is assumed to be OSS of
+// an unknown version, which passes every version check.
+func detectServer(ctx context.Context, influx influxdb2.Client) serverInfo {
+	info := serverInfo{}
+	if u, err := url.Parse(influx.ServerURL()); err == nil && strings.HasSuffix(u.Hostname(), ".cloud2.influxdata.com") {
+		info.cloud = true
+		return info
+	}
+	health, err := influx.Health(ctx)
+	if err != nil {
+		log.Printf("[WARN] error reading the server version from /health: %s", err)
+		return info
+	}
+	if strings.Contains(strings.ToLower(health.Name), "cloud") {
+		info.cloud = true
+	}
+	if health.Version != nil && !info.cloud {
+		info.version = strings.TrimPrefix(*health.Version, "v")
+	}
+	return info
+}
+
+// atLeast reports whether the server is OSS of at least the given version, or
+// of an unknown version.
+func (s serverInfo) atLeast(version string) bool {
+	have, ok := parseVersion(s.version)
+	if !ok {
+		return true
+	}
+	want, _ := parseVersion(version)
+	for i := range want {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+// requireVersion returns an error explaining that feature is not available on
+// OSS servers older than version.
+func (s serverInfo) requireVersion(feature, version string) error {
+	if !s.atLeast(version) {
+		return fmt.Errorf("%s require InfluxDB %s or later, the server is running %s", feature, version, s.version)
+	}
+	return nil
+}
+
+// parseVersion reads the major, minor and patch numbers of a version such as
+// 2.7.1 or 2.7.1-rc1.
+func parseVersion(version string) ([3]int, bool) {
+	var result [3]int
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return result, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return result, false
+		}
+		result[i] = n
+	}
+	return result, true
+}
@@ -0,0 +1,55 @@
+package influxdbv2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+func TestDetectServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"influxdb","status":"pass","version":"v2.7.1","commit":"407fa622e9"}`))
+	}))
+	defer server.Close()
+
+	info := detectServer(context.Background(), influxdb2.NewClient(server.URL, ""))
+	if info.cloud || info.version != "2.7.1" {
+		t.Fatalf("expected OSS 2.7.1 but got %+v", info)
+	}
+	if err := info.requireVersion("shard group durations", "2.0.4"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := info.requireVersion("shard group durations", "2.8.0"); err == nil {
+		t.Fatal("expected newer versions to be required")
+	}
+
+	info = detectServer(context.Background(), influxdb2.NewClient("https://us-east-1-1.aws.cloud2.influxdata.com", ""))
+	if !info.cloud {
+		t.Fatal("expected InfluxDB Cloud to be detected from its URL")
+	}
+}
+
+func TestServerInfoAtLeast(t *testing.T) {
+	cases := []struct {
+		version string
+		want    string
+		result  bool
+	}{
+		{"2.7.1", "2.0.4", true},
+		{"2.0.4", "2.0.4", true},
+		{"2.0.3", "2.0.4", false},
+		{"2.10.0", "2.9.1", true},
+		{"2.7.0-rc1", "2.7.0", true},
+		{"", "2.0.4", true},
+		{"dev", "2.0.4", true},
+	}
+	for _, c := range cases {
+		if result := (serverInfo{version: c.version}).atLeast(c.want); result != c.result {
+			t.Errorf("expected %q at least %q to be %t", c.version, c.want, c.result)
+		}
+	}
+}
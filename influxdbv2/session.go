@@ -0,0 +1,40 @@
+package influxdbv2
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+)
+
+// sessions holds the clients that signed in with a username and password so
+// that their sessions can be ended when the provider shuts down.
+var sessions struct {
+	sync.Mutex
+	clients []influxdb2.Client
+}
+
+func trackSession(client influxdb2.Client) {
+	sessions.Lock()
+	defer sessions.Unlock()
+	sessions.clients = append(sessions.clients, client)
+}
+
+// SignOut ends every session opened by the provider. It is meant to be called
+// once the plugin has stopped serving requests.
+func SignOut() {
+	sessions.Lock()
+	defer sessions.Unlock()
+	for _, client := range sessions.clients {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := client.UsersAPI().SignOut(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("[WARN] error signing out of %s: %s", client.ServerURL(), err)
+		}
+		client.Close()
+	}
+	sessions.clients = nil
+}
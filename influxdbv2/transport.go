@@ -0,0 +1,266 @@
+package influxdbv2
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+type transportOptions struct {
+	// When set, every request attempt is logged with this context
+	logContext context.Context
+	// Added to every request
+	headers               map[string]string
+	maxRetries            int
+	maxConcurrentRequests int
+	requestTimeout        time.Duration
+	minBackoff            time.Duration
+	maxBackoff            time.Duration
+}
+
+// newHTTPClient builds the http client shared by the SDK and legacy clients.
+// Requests are capped in concurrency, retried when the server is rate limiting
+// or unavailable, and each attempt is bounded by the request timeout.
+func newHTTPClient(base *http.Transport, options transportOptions) *http.Client {
+	var transport http.RoundTripper = base
+	if options.requestTimeout > 0 {
+		transport = &timeoutTransport{next: transport, timeout: options.requestTimeout}
+	}
+	if options.logContext != nil {
+		var headers []string
+		for key := range options.headers {
+			headers = append(headers, key)
+		}
+		transport = &loggingTransport{ctx: options.logContext, next: transport, headers: headers}
+	}
+	if len(options.headers) > 0 {
+		transport = &headerTransport{next: transport, headers: options.headers}
+	}
+	transport = &retryTransport{
+		next:       transport,
+		maxRetries: options.maxRetries,
+		minBackoff: options.minBackoff,
+		maxBackoff: options.maxBackoff,
+	}
+	if options.maxConcurrentRequests > 0 {
+		transport = &limitTransport{next: transport, slots: make(chan struct{}, options.maxConcurrentRequests)}
+	}
+	return &http.Client{Transport: transport}
+}
+
+func newBaseTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// newProxyFunc routes requests through proxyURL, except for the hosts excluded
+// by the NO_PROXY environment variable.
+func newProxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if _, err := url.Parse(proxyURL); err != nil {
+		return nil, err
+	}
+	config := httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    httpproxy.FromEnvironment().NoProxy,
+	}
+	proxy := config.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// headerTransport sets additional headers on every request.
+type headerTransport struct {
+	next    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// limitTransport caps the number of requests in flight. A request keeps its
+// slot while it is being retried so that a rate limited server isn't flooded.
+type limitTransport struct {
+	next  http.RoundTripper
+	slots chan struct{}
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-t.slots }()
+	return t.next.RoundTrip(req)
+}
+
+// retryTransport retries requests that the server rejected because it is rate
+// limiting or temporarily unavailable, backing off exponentially between
+// attempts unless the server says how long to wait with Retry-After. Requests
+// that aren't idempotent are only retried when the server surely refused them.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !isRetryable(req, resp) || attempt >= t.maxRetries {
+			return resp, err
+		}
+		// A body that cannot be replayed cannot be retried
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait, ok := t.backoff(attempt, resp)
+		if !ok {
+			return resp, nil
+		}
+		// Drain the body so that the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// backoff returns how long to wait before the next attempt. A server that asks
+// to wait for longer than the maximum backoff is given up on, rather than
+// blocking the provider for as long as it says.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return wait, wait <= t.maxBackoff
+	}
+	wait := t.minBackoff << attempt
+	if wait <= 0 || wait > t.maxBackoff {
+		wait = t.maxBackoff
+	}
+	// Jitter spreads out the retries of requests that failed together
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
+
+// isRetryable tells whether a request can be sent again after its response. A
+// gateway error doesn't tell whether the server applied the request, so only
+// idempotent requests are retried then. Other requests are only retried when
+// rate limited, or when unavailable for as long as Retry-After says.
+func isRetryable(req *http.Request, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return isIdempotent(req.Method) || resp.Header.Get("Retry-After") != ""
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+type withoutRequestTimeoutKey struct{}
+
+// withoutRequestTimeout marks the requests made with ctx as allowed to run for
+// longer than the request timeout, such as copying the data of a bucket or
+// deleting data.
+func withoutRequestTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutRequestTimeoutKey{}, true)
+}
+
+// timeoutTransport bounds each attempt of a request, including reading its
+// response body.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(withoutRequestTimeoutKey{}) != nil {
+		return t.next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
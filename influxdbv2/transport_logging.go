@@ -0,0 +1,148 @@
+package influxdbv2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const redacted = "[REDACTED]"
+
+// Headers carrying credentials, a token, basic auth, an API key or a session
+// cookie. The headers configured on the provider are redacted as well, as they
+// are often used for credentials of proxies and gateways.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "Cookie", "Set-Cookie"}
+
+// JSON fields carrying secrets, such as the password sent to set a legacy
+// authorization password or the token of a newly created authorization
+var redactedFields = map[string]bool{
+	"password": true,
+	"token":    true,
+}
+
+// Headers that identify a request on the server side, in order of preference
+var requestIDHeaders = []string{"Request-Id", "X-Request-Id", "Trace-Id"}
+
+// loggingTransport logs every request attempt through tflog. Requests are made
+// with contexts that carry no logger, so the provider's context is used.
+type loggingTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+	// Names of the headers configured on the provider
+	headers []string
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fields := map[string]interface{}{
+		"method":          req.Method,
+		"url":             req.URL.String(),
+		"request_headers": redactHeaders(req.Header, t.headers),
+	}
+	if req.Body != nil && req.GetBody != nil && isJSON(req.Header) {
+		body, err := req.GetBody()
+		if err == nil {
+			fields["request_body"] = readRedactedBody(body)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["latency_ms"] = time.Since(start).Milliseconds()
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.Debug(t.ctx, "InfluxDB request failed", fields)
+		return resp, err
+	}
+
+	fields["status"] = resp.StatusCode
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			fields["request_id"] = id
+			break
+		}
+	}
+	if isJSON(resp.Header) {
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		fields["response_body"] = redactBody(body)
+	}
+	tflog.Debug(t.ctx, "InfluxDB request", fields)
+	return resp, nil
+}
+
+func isJSON(header http.Header) bool {
+	ctype, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return ctype == "application/json"
+}
+
+// redactHeaders returns the values of headers, with those of redactedHeaders
+// and of the other given names redacted.
+func redactHeaders(header http.Header, names []string) map[string]string {
+	result := map[string]string{}
+	for key := range header {
+		result[key] = strings.Join(header.Values(key), ", ")
+	}
+	for _, keys := range [][]string{redactedHeaders, names} {
+		for _, key := range keys {
+			if header.Get(key) != "" {
+				result[http.CanonicalHeaderKey(key)] = redacted
+			}
+		}
+	}
+	return result
+}
+
+func readRedactedBody(body io.ReadCloser) string {
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	return redactBody(b)
+}
+
+// redactBody replaces the value of secret fields anywhere in a JSON document.
+// Bodies that cannot be decoded are not logged as they could contain anything.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return redacted
+	}
+	redactValue(document)
+	b, err := json.Marshal(document)
+	if err != nil {
+		return redacted
+	}
+	return string(b)
+}
+
+func redactValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if redactedFields[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			redactValue(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			redactValue(child)
+		}
+	}
+}
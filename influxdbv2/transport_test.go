@@ -0,0 +1,252 @@
+package influxdbv2
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("body was not replayed, got %q", body)
+		}
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(newBaseTransport(), transportOptions{
+		maxRetries: 3,
+		minBackoff: time.Millisecond,
+		maxBackoff: time.Millisecond,
+	})
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d", resp.StatusCode)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts but got %d", attempts)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(newBaseTransport(), transportOptions{
+		maxRetries: 2,
+		minBackoff: time.Millisecond,
+		maxBackoff: time.Millisecond,
+	})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503 but got %d", resp.StatusCode)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts but got %d", attempts)
+	}
+}
+
+func TestRetryTransportLongRetryAfter(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(newBaseTransport(), transportOptions{
+		maxRetries: 3,
+		minBackoff: time.Millisecond,
+		maxBackoff: time.Second,
+	})
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429 but got %d", resp.StatusCode)
+	}
+	if attempts != 1 || time.Since(start) > time.Second {
+		t.Fatalf("expected to give up at once but made %d attempts in %s", attempts, time.Since(start))
+	}
+}
+
+func TestRetryTransportNonIdempotent(t *testing.T) {
+	cases := []struct {
+		status     int
+		retryAfter string
+		attempts   int32
+	}{
+		{http.StatusTooManyRequests, "", 3},
+		{http.StatusServiceUnavailable, "0", 3},
+		// The server may have applied the request before failing to answer
+		{http.StatusServiceUnavailable, "", 1},
+		{http.StatusBadGateway, "", 1},
+		{http.StatusGatewayTimeout, "", 1},
+	}
+	for _, c := range cases {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			if c.retryAfter != "" {
+				w.Header().Set("Retry-After", c.retryAfter)
+			}
+			w.WriteHeader(c.status)
+		}))
+
+		client := newHTTPClient(newBaseTransport(), transportOptions{
+			maxRetries: 2,
+			minBackoff: time.Millisecond,
+			maxBackoff: time.Millisecond,
+		})
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+		server.Close()
+		if attempts != c.attempts {
+			t.Errorf("expected %d attempts of a POST answered %d but got %d", c.attempts, c.status, attempts)
+		}
+	}
+}
+
+func TestLimitTransport(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(newBaseTransport(), transportOptions{maxConcurrentRequests: 2})
+	done := make(chan struct{})
+	for i := 0; i < 6; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("err: %s", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	for i := 0; i < 6; i++ {
+		<-done
+	}
+	if maxInFlight > 2 {
+		t.Fatalf("expected at most 2 requests in flight but got %d", maxInFlight)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if wait, ok := parseRetryAfter("7"); !ok || wait != 7*time.Second {
+		t.Fatalf("expected 7s but got %s", wait)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if wait, ok := parseRetryAfter(date); !ok || wait <= 0 || wait > time.Minute {
+		t.Fatalf("expected up to a minute but got %s", wait)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Fatal("expected an invalid value to be ignored")
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	body := redactBody([]byte(`{"id":"1","token":"secret","permissions":[{"action":"read"}],"links":{"password":"hunter22"}}`))
+	if strings.Contains(body, "secret") || strings.Contains(body, "hunter22") {
+		t.Fatalf("secrets were not redacted: %s", body)
+	}
+	if !strings.Contains(body, `"id":"1"`) || !strings.Contains(body, `"action":"read"`) {
+		t.Fatalf("non secret fields should be kept: %s", body)
+	}
+	if redactBody([]byte("password=hunter22")) != redacted {
+		t.Fatal("bodies that cannot be decoded should not be logged")
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Token secret")
+	header.Set("Proxy-Authorization", "Basic secret")
+	header.Set("X-Api-Key", "secret")
+	header.Set("X-Gateway-Secret", "secret")
+	header.Set("Content-Type", "application/json")
+	// Headers of the provider are redacted whatever their case
+	result := redactHeaders(header, []string{"x-gateway-secret"})
+	for _, key := range []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "X-Gateway-Secret"} {
+		if result[key] != redacted {
+			t.Fatalf("expected %s to be redacted but got %q", key, result[key])
+		}
+	}
+	if result["Content-Type"] != "application/json" {
+		t.Fatalf("expected Content-Type to be kept but got %q", result["Content-Type"])
+	}
+}
+
+func TestHeaderTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "acme" {
+			t.Errorf("expected X-Tenant to be acme but got %q", r.Header.Get("X-Tenant"))
+		}
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(newBaseTransport(), transportOptions{headers: map[string]string{"X-Tenant": "acme"}})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example.com")
+	proxy, err := newProxyFunc("http://proxy.example.com:3128")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://influxdb.example.com/api/v2/buckets", nil)
+	if u, err := proxy(req); err != nil || u == nil || u.Host != "proxy.example.com:3128" {
+		t.Fatalf("expected the request to go through the proxy but got %v, %v", u, err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, "https://internal.example.com/api/v2/buckets", nil)
+	if u, err := proxy(req); err != nil || u != nil {
+		t.Fatalf("expected hosts in NO_PROXY to be reached directly but got %v, %v", u, err)
+	}
+}
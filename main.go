@@ -11,4 +11,5 @@ import (
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: influxdbv2.Provider})
+	influxdbv2.SignOut()
 }